@@ -0,0 +1,426 @@
+package azuretpl
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	msgraphsdk "github.com/microsoftgraph/msgraph-sdk-go"
+)
+
+const (
+	AuthMethodAzCli             = "azcli"
+	AuthMethodDefault           = "default"
+	AuthMethodEnvironment       = "environment"
+	AuthMethodWorkloadIdentity  = "workloadidentity"
+	AuthMethodManagedIdentity   = "managedidentity"
+	AuthMethodClientSecret      = "clientsecret"
+	AuthMethodClientCertificate = "clientcertificate"
+	AuthMethodOidc              = "oidc"
+
+	EnvAzureTenantID                  = "AZURE_TENANT_ID"
+	EnvAzureClientID                  = "AZURE_CLIENT_ID"
+	EnvAzureClientSecret              = "AZURE_CLIENT_SECRET"
+	EnvAzureClientCertificatePath     = "AZURE_CLIENT_CERTIFICATE_PATH"
+	EnvAzureClientCertificatePassword = "AZURE_CLIENT_CERTIFICATE_PASSWORD"
+	EnvAzureFederatedToken            = "AZURE_FEDERATED_TOKEN"
+	EnvAzureFederatedTokenFile        = "AZURE_FEDERATED_TOKEN_FILE"
+	EnvAzureSubscriptionID            = "AZURE_SUBSCRIPTION_ID"
+
+	// GitHub Actions OIDC token request
+	envGithubOidcRequestUrl   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	envGithubOidcRequestToken = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+
+	// Azure DevOps workload identity federation
+	envAzurePipelinesOidcRequestUri      = "SYSTEM_OIDCREQUESTURI"
+	envAzurePipelinesServiceConnectionID = "AZURESUBSCRIPTION_SERVICE_CONNECTION_ID"
+	envAzurePipelinesSystemAccessToken   = "SYSTEM_ACCESSTOKEN"
+
+	azureFederatedTokenAudience         = "api://AzureADTokenExchange"
+	azureResourceManagerDefaultAudience = "https://management.azure.com"
+)
+
+var (
+	// azureCredentials are the shared credentials per auth profile (empty name for --auth.method), clientLock must be held
+	azureCredentials = map[string]azcore.TokenCredential{}
+
+	// msGraphServiceClients are the MsGraph clients using azureCredentials, clientLock must be held
+	msGraphServiceClients = map[string]*msgraphsdk.GraphServiceClient{}
+)
+
+// authMethod returns the auth method of the current auth profile (azcli if not set)
+func (e *AzureTemplateExecutor) authMethod() string {
+	method := e.opts.Auth.Method
+	if e.authProfile != nil {
+		method = e.authProfile.Method
+	}
+
+	if method == "" {
+		return AuthMethodAzCli
+	}
+	return method
+}
+
+// credential returns the credential of the current auth profile, used for all Azure SDK clients
+func (e *AzureTemplateExecutor) credential() (azcore.TokenCredential, error) {
+	clientLock.Lock()
+	defer clientLock.Unlock()
+
+	armClient, err := e.initAzureClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if e.authProfile == nil && e.authMethod() == AuthMethodAzCli {
+		return armClient.GetCred(), nil
+	}
+
+	if _, exists := azureCredentials[e.authProfileName()]; !exists {
+		clientOptions := azcore.ClientOptions{}
+		if val := armClient.NewAzCoreClientOptions(); val != nil {
+			clientOptions = *val
+		}
+
+		clientOptions.Cloud, err = e.cloudConfiguration(clientOptions.Cloud)
+		if err != nil {
+			return nil, err
+		}
+
+		cred, err := e.newCredential(clientOptions)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to create Azure credential for auth method "%v": %w`, e.authMethod(), err)
+		}
+		azureCredentials[e.authProfileName()] = cred
+	}
+
+	return azureCredentials[e.authProfileName()], nil
+}
+
+// msGraphCredentialClient returns the MsGraph client using the credential of the current auth profile
+func (e *AzureTemplateExecutor) msGraphCredentialClient() (*msgraphsdk.GraphServiceClient, error) {
+	cred, err := e.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := e.cloudEndpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	clientLock.Lock()
+	defer clientLock.Unlock()
+
+	if client, exists := msGraphServiceClients[e.authProfileName()]; exists {
+		return client, nil
+	}
+
+	endpoint := strings.TrimSuffix(endpoints.MicrosoftGraphEndpoint, "/")
+	if endpoint == "" {
+		return nil, fmt.Errorf(`unable to create MsGraph client: Azure cloud "%v" has no microsoftGraphEndpoint, please set it in the cloud config (--cloud.config)`, endpoints.Name)
+	}
+
+	client, err := msgraphsdk.NewGraphServiceClientWithCredentials(cred, []string{endpoint + "/.default"})
+	if err != nil {
+		return nil, fmt.Errorf(`unable to create MsGraph client: %w`, err)
+	}
+	client.GetAdapter().SetBaseUrl(endpoint + "/v1.0")
+
+	msGraphServiceClients[e.authProfileName()] = client
+	return client, nil
+}
+
+// newCredential creates the credential of the selected auth method using the standard AZURE_* env vars
+// (or the values of the current auth profile)
+func (e *AzureTemplateExecutor) newCredential(clientOptions azcore.ClientOptions) (azcore.TokenCredential, error) {
+	tenantID := e.authEnv(EnvAzureTenantID)
+	clientID := e.authEnv(EnvAzureClientID)
+
+	switch e.authMethod() {
+	case AuthMethodAzCli:
+		// only used for auth profiles, eg. to select another tenant
+		return azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{
+			TenantID: tenantID,
+		})
+	case AuthMethodDefault:
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			ClientOptions: clientOptions,
+			TenantID:      tenantID,
+		})
+	case AuthMethodEnvironment:
+		return azidentity.NewEnvironmentCredential(&azidentity.EnvironmentCredentialOptions{
+			ClientOptions: clientOptions,
+		})
+	case AuthMethodWorkloadIdentity:
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientOptions: clientOptions,
+			TenantID:      tenantID,
+			ClientID:      clientID,
+			TokenFilePath: e.authEnv(EnvAzureFederatedTokenFile),
+		})
+	case AuthMethodManagedIdentity:
+		options := &azidentity.ManagedIdentityCredentialOptions{
+			ClientOptions: clientOptions,
+		}
+		if clientID != "" {
+			// user assigned managed identity
+			options.ID = azidentity.ClientID(clientID)
+		}
+		return azidentity.NewManagedIdentityCredential(options)
+	case AuthMethodClientSecret:
+		if err := e.requireAuthEnv(EnvAzureTenantID, EnvAzureClientID, EnvAzureClientSecret); err != nil {
+			return nil, err
+		}
+		return azidentity.NewClientSecretCredential(tenantID, clientID, e.authEnv(EnvAzureClientSecret), &azidentity.ClientSecretCredentialOptions{
+			ClientOptions: clientOptions,
+		})
+	case AuthMethodClientCertificate:
+		if err := e.requireAuthEnv(EnvAzureTenantID, EnvAzureClientID, EnvAzureClientCertificatePath); err != nil {
+			return nil, err
+		}
+
+		certData, err := os.ReadFile(e.authEnv(EnvAzureClientCertificatePath))
+		if err != nil {
+			return nil, fmt.Errorf(`unable to read client certificate: %w`, err)
+		}
+
+		certs, key, err := azidentity.ParseCertificates(certData, []byte(e.authEnv(EnvAzureClientCertificatePassword)))
+		if err != nil {
+			return nil, fmt.Errorf(`unable to parse client certificate: %w`, err)
+		}
+
+		return azidentity.NewClientCertificateCredential(tenantID, clientID, certs, key, &azidentity.ClientCertificateCredentialOptions{
+			ClientOptions: clientOptions,
+		})
+	case AuthMethodOidc:
+		if err := e.requireAuthEnv(EnvAzureTenantID, EnvAzureClientID); err != nil {
+			return nil, err
+		}
+
+		// Azure DevOps service connection using workload identity federation
+		if e.authEnv(envAzurePipelinesOidcRequestUri) != "" && e.authEnv(envAzurePipelinesServiceConnectionID) != "" {
+			return azidentity.NewAzurePipelinesCredential(
+				tenantID,
+				clientID,
+				e.authEnv(envAzurePipelinesServiceConnectionID),
+				e.authEnv(envAzurePipelinesSystemAccessToken),
+				&azidentity.AzurePipelinesCredentialOptions{
+					ClientOptions: clientOptions,
+				},
+			)
+		}
+
+		return azidentity.NewClientAssertionCredential(tenantID, clientID, e.fetchFederatedToken, &azidentity.ClientAssertionCredentialOptions{
+			ClientOptions: clientOptions,
+		})
+	}
+
+	return nil, fmt.Errorf(`unsupported auth method "%v"`, e.authMethod())
+}
+
+// fetchFederatedToken returns the OIDC token for federated credentials
+// (from env var, token file or GitHub Actions)
+func (e *AzureTemplateExecutor) fetchFederatedToken(ctx context.Context) (string, error) {
+	if token := e.authEnv(EnvAzureFederatedToken); token != "" {
+		return token, nil
+	}
+
+	if path := e.authEnv(EnvAzureFederatedTokenFile); path != "" {
+		token, err := os.ReadFile(path) // #nosec G304 token file is set by user
+		if err != nil {
+			return "", fmt.Errorf(`unable to read federated token file: %w`, err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+
+	if requestUrl := e.authEnv(envGithubOidcRequestUrl); requestUrl != "" {
+		return fetchGithubOidcToken(ctx, requestUrl, e.authEnv(envGithubOidcRequestToken))
+	}
+
+	return "", fmt.Errorf(`no federated token found, please set %v, %v or run inside GitHub Actions with "id-token: write" permission`, EnvAzureFederatedToken, EnvAzureFederatedTokenFile)
+}
+
+// fetchGithubOidcToken requests OIDC token from GitHub Actions
+func fetchGithubOidcToken(ctx context.Context, requestUrl, requestToken string) (string, error) {
+	parsedUrl, err := url.Parse(requestUrl)
+	if err != nil {
+		return "", fmt.Errorf(`invalid GitHub OIDC request url: %w`, err)
+	}
+	query := parsedUrl.Query()
+	query.Set("audience", azureFederatedTokenAudience)
+	parsedUrl.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsedUrl.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf(`unable to request GitHub OIDC token: %w`, err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(`unable to request GitHub OIDC token: unexpected status "%v"`, resp.Status)
+	}
+
+	var result struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf(`unable to parse GitHub OIDC token response: %w`, err)
+	}
+
+	return result.Value, nil
+}
+
+// AccountInfo detects information about the Azure account (like "az account show"),
+// results are always fetched (no cache or fixtures) as they are used to detect the current tenant
+func (e *AzureTemplateExecutor) AccountInfo() (map[string]interface{}, error) {
+	accountInfo, err := e.fetchAccountInfo()
+	if err != nil {
+		return nil, err
+	}
+	e.azureCliAccountInfo = accountInfo
+	return accountInfo, nil
+}
+
+// fetchCredentialAccountInfo builds account information (format of "az account show") using the credential,
+// tenant and identity are read from the access token, the subscription from AZURE_SUBSCRIPTION_ID
+// (or the only visible subscription)
+func (e *AzureTemplateExecutor) fetchCredentialAccountInfo() (map[string]interface{}, error) {
+	armClient, err := e.azureClient()
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := e.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	cloudConfig, err := e.cloudConfiguration(armClient.GetCloudConfig().Configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	audience := cloudConfig.Services[cloud.ResourceManager].Audience
+	if audience == "" {
+		audience = azureResourceManagerDefaultAudience
+	}
+
+	token, err := cred.GetToken(e.ctx, policy.TokenRequestOptions{
+		Scopes: []string{strings.TrimSuffix(audience, "/") + "/.default"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf(`unable to fetch Azure access token: %w`, err)
+	}
+
+	claims, err := parseTokenClaims(token.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	user := map[string]interface{}{
+		"name": claims["appid"],
+		"type": "servicePrincipal",
+	}
+	if upn, ok := claims["upn"].(string); ok && upn != "" {
+		user["name"] = upn
+		user["type"] = "user"
+	}
+
+	tenantID := e.authEnv(EnvAzureTenantID)
+	if val, ok := claims["tid"].(string); ok && val != "" {
+		tenantID = val
+	}
+
+	ret := map[string]interface{}{
+		"environmentName": string(armClient.GetCloudName()),
+		"tenantId":        tenantID,
+		"homeTenantId":    tenantID,
+		"user":            user,
+		"isDefault":       true,
+	}
+
+	subscriptionID := e.authEnv(EnvAzureSubscriptionID)
+	if subscriptionID == "" {
+		subscriptionList, err := e.providers.Resource.ListSubscriptions(e.ctx)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to list Azure subscriptions: %w`, err)
+		}
+
+		if len(subscriptionList) != 1 {
+			// no default subscription without Azure CLI
+			e.logger.Warn(fmt.Sprintf(`unable to detect current Azure subscription (found %d subscriptions), please set %v`, len(subscriptionList), EnvAzureSubscriptionID))
+			return ret, nil
+		}
+
+		if subscriptionList[0].SubscriptionID != nil {
+			subscriptionID = *subscriptionList[0].SubscriptionID
+		}
+	}
+
+	subscription, err := e.providers.Resource.GetSubscription(e.ctx, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to fetch Azure subscription "%v": %w`, subscriptionID, err)
+	}
+
+	ret["id"] = subscriptionID
+	if subscription.DisplayName != nil {
+		ret["name"] = *subscription.DisplayName
+	}
+	if subscription.State != nil {
+		ret["state"] = string(*subscription.State)
+	}
+
+	return ret, nil
+}
+
+// parseTokenClaims returns the claims of a JWT access token (without validation)
+func parseTokenClaims(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New(`unable to parse Azure access token: invalid format`)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf(`unable to parse Azure access token: %w`, err)
+	}
+
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf(`unable to parse Azure access token: %w`, err)
+	}
+
+	return claims, nil
+}
+
+// requireAuthEnv checks that all env vars are set (or set in the current auth profile)
+func (e *AzureTemplateExecutor) requireAuthEnv(names ...string) error {
+	missing := []string{}
+	for _, name := range names {
+		if e.authEnv(name) == "" {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf(`missing env vars %v`, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
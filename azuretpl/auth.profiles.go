@@ -0,0 +1,130 @@
+package azuretpl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+type (
+	// AuthProfileFile contains named credential profiles (see --auth.profiles)
+	AuthProfileFile struct {
+		Profiles map[string]*AuthProfile `json:"profiles"`
+	}
+
+	// AuthProfile is a named credential which can be selected in templates using azWithProfile
+	AuthProfile struct {
+		name string
+
+		Method         string `json:"method"`
+		TenantID       string `json:"tenantId"`
+		ClientID       string `json:"clientId"`
+		SubscriptionID string `json:"subscriptionId"`
+
+		// Env contains additional AZURE_* values of the auth method (eg. AZURE_CLIENT_SECRET),
+		// all values are expanded (eg. ${PROD_CLIENT_SECRET})
+		Env map[string]string `json:"env"`
+	}
+)
+
+var (
+	authProfileFiles     = map[string]*AuthProfileFile{}
+	authProfileFilesLock sync.Mutex
+
+	authMethods = map[string]bool{
+		AuthMethodAzCli:             true,
+		AuthMethodDefault:           true,
+		AuthMethodEnvironment:       true,
+		AuthMethodWorkloadIdentity:  true,
+		AuthMethodManagedIdentity:   true,
+		AuthMethodClientSecret:      true,
+		AuthMethodClientCertificate: true,
+		AuthMethodOidc:              true,
+	}
+)
+
+// loadAuthProfileFile reads the auth profile file (yaml or json), files are only read once
+func loadAuthProfileFile(path string) (*AuthProfileFile, error) {
+	authProfileFilesLock.Lock()
+	defer authProfileFilesLock.Unlock()
+
+	if file, exists := authProfileFiles[path]; exists {
+		return file, nil
+	}
+
+	content, err := os.ReadFile(path) // #nosec G304 profile file is set by user
+	if err != nil {
+		return nil, fmt.Errorf(`unable to read auth profile file "%v": %w`, path, err)
+	}
+
+	file := &AuthProfileFile{}
+	if err := yaml.UnmarshalStrict(content, file); err != nil {
+		return nil, fmt.Errorf(`unable to parse auth profile file "%v": %w`, path, err)
+	}
+
+	for name, profile := range file.Profiles {
+		if profile == nil {
+			profile = &AuthProfile{}
+			file.Profiles[name] = profile
+		}
+		profile.name = name
+
+		if profile.Method != "" && !authMethods[profile.Method] {
+			return nil, fmt.Errorf(`unsupported auth method "%v" for auth profile "%v" in "%v"`, profile.Method, name, path)
+		}
+	}
+
+	authProfileFiles[path] = file
+	return file, nil
+}
+
+// loadAuthProfile returns the auth profile by name from the file set by --auth.profiles
+func (e *AzureTemplateExecutor) loadAuthProfile(name string) (*AuthProfile, error) {
+	if e.opts.Auth.Profiles == "" {
+		return nil, fmt.Errorf(`unable to use auth profile "%v", no auth profile file set (see --auth.profiles)`, name)
+	}
+
+	file, err := loadAuthProfileFile(e.opts.Auth.Profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, exists := file.Profiles[name]
+	if !exists {
+		return nil, fmt.Errorf(`auth profile "%v" not found in "%v"`, name, e.opts.Auth.Profiles)
+	}
+
+	return profile, nil
+}
+
+// authProfileName returns the name of the current auth profile (empty for the default credential set by --auth.method)
+func (e *AzureTemplateExecutor) authProfileName() string {
+	if e.authProfile != nil {
+		return e.authProfile.name
+	}
+	return ""
+}
+
+// authEnv returns the value of env var name, AZURE_* values are only taken from the current auth profile (if used)
+// so credentials of different profiles are never mixed
+func (e *AzureTemplateExecutor) authEnv(name string) string {
+	if e.authProfile != nil && strings.HasPrefix(name, "AZURE_") {
+		return e.authProfile.env(name)
+	}
+	return os.Getenv(name)
+}
+
+func (p *AuthProfile) env(name string) string {
+	switch name {
+	case EnvAzureTenantID:
+		return os.ExpandEnv(p.TenantID)
+	case EnvAzureClientID:
+		return os.ExpandEnv(p.ClientID)
+	case EnvAzureSubscriptionID:
+		return os.ExpandEnv(p.SubscriptionID)
+	}
+	return os.ExpandEnv(p.Env[name])
+}
@@ -2,27 +2,50 @@ package azuretpl
 
 import (
 	"encoding/json"
-	"log/slog"
+	"fmt"
 	"os"
 	"os/exec"
 )
 
 func (e *AzureTemplateExecutor) azAccountInfo() (interface{}, error) {
 	if e.azureCliAccountInfo == nil {
-		cmd := exec.Command("az", "account", "show", "-o", "json")
-		cmd.Stderr = os.Stderr
-
-		accountInfo, err := cmd.Output()
+		// account information depends on the auth method, identity and the current tenant/subscription (eg. changed by az login)
+		cacheKey := generateCacheKey(`azAccountInfo`, e.authMethod(), e.authEnv(EnvAzureTenantID), e.authEnv(EnvAzureClientID), e.authEnv(EnvAzureSubscriptionID))
+		result, err := e.cacheResult(cacheKey, func() (interface{}, error) {
+			return e.fetchAccountInfo()
+		})
 		if err != nil {
-			e.logger.Error(`unable to detect Azure TenantID via 'az account show'`, slog.Any("error", err))
-			os.Exit(1)
+			return nil, err
 		}
 
-		err = json.Unmarshal(accountInfo, &e.azureCliAccountInfo)
-		if err != nil {
-			e.logger.Error(`unable to parse 'az account show' output`, slog.Any("error", err))
-			os.Exit(1)
+		if val, ok := result.(map[string]interface{}); ok {
+			e.azureCliAccountInfo = val
+		} else {
+			return nil, fmt.Errorf(`unable to use Azure account information, got unexpected type "%T"`, result)
 		}
 	}
 	return e.azureCliAccountInfo, nil
 }
+
+// fetchAccountInfo fetches account information using the Azure CLI ("az account show") or the credential of the auth method
+func (e *AzureTemplateExecutor) fetchAccountInfo() (map[string]interface{}, error) {
+	if e.authMethod() != AuthMethodAzCli {
+		return e.fetchCredentialAccountInfo()
+	}
+
+	cmd := exec.Command("az", "account", "show", "-o", "json")
+	cmd.Stderr = os.Stderr
+
+	accountInfo, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf(`unable to detect Azure TenantID via 'az account show': %w`, err)
+	}
+
+	var ret map[string]interface{}
+	err = json.Unmarshal(accountInfo, &ret)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to parse 'az account show' output: %w`, err)
+	}
+
+	return ret, nil
+}
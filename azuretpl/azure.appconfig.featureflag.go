@@ -0,0 +1,452 @@
+package azuretpl
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/webdevops/go-common/utils/to"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
+)
+
+const (
+	AppConfigFeatureFlagKeyPrefix   = ".appconfig.featureflag/"
+	AppConfigContentTypeFeatureFlag = "application/vnd.microsoft.appconfig.ff+json"
+
+	AppConfigFeatureFlagFilterTargeting  = "Microsoft.Targeting"
+	AppConfigFeatureFlagFilterTimeWindow = "Microsoft.TimeWindow"
+
+	AppConfigFeatureFlagRequirementAll = "All"
+	AppConfigFeatureFlagRequirementAny = "Any"
+)
+
+type (
+	// appConfigFeatureFlagValue is the json value of a feature flag setting
+	appConfigFeatureFlagValue struct {
+		Id          string `json:"id"`
+		Description string `json:"description"`
+		DisplayName string `json:"display_name"`
+		Enabled     bool   `json:"enabled"`
+		Conditions  *struct {
+			RequirementType string `json:"requirement_type"`
+			ClientFilters   []struct {
+				Name       string                 `json:"name"`
+				Parameters map[string]interface{} `json:"parameters"`
+			} `json:"client_filters"`
+		} `json:"conditions"`
+		Variants []struct {
+			Name               string      `json:"name"`
+			ConfigurationValue interface{} `json:"configuration_value"`
+			StatusOverride     string      `json:"status_override"`
+		} `json:"variants"`
+		Allocation map[string]interface{} `json:"allocation"`
+	}
+
+	// appConfigFeatureFlagContext is the context for evaluating client filters of feature flags
+	appConfigFeatureFlagContext struct {
+		// User is used by targeting filters
+		User string
+
+		// Groups are used by targeting filters
+		Groups []string
+
+		// Time is used by time window filters (default: now)
+		Time time.Time
+	}
+
+	// appConfigFeatureFlagOptions are the options of azAppConfigFeatureFlag and azAppConfigFeatureFlagList
+	appConfigFeatureFlagOptions struct {
+		appConfigFeatureFlagContext
+
+		// Labels is the label fallback chain (only used for lists)
+		Labels []string
+	}
+
+	// appConfigTargetingParameters are the parameters of the Microsoft.Targeting filter
+	appConfigTargetingParameters struct {
+		Audience struct {
+			Users  []string
+			Groups []struct {
+				Name              string
+				RolloutPercentage float64
+			}
+			DefaultRolloutPercentage float64
+			Exclusion                struct {
+				Users  []string
+				Groups []string
+			}
+		}
+	}
+
+	// appConfigTimeWindowParameters are the parameters of the Microsoft.TimeWindow filter
+	appConfigTimeWindowParameters struct {
+		Start      string
+		End        string
+		Recurrence interface{}
+	}
+)
+
+// azAppConfigFeatureFlag fetches feature flag from Azure AppConfig and evaluates its client filters
+// (targeting and time window) for the optional evaluation context (user, groups, time)
+func (e *AzureTemplateExecutor) azAppConfigFeatureFlag(appConfigUrl string, flagName string, label string, evaluationContext ...map[string]interface{}) (interface{}, error) {
+	flagOptions, err := parseAppConfigFeatureFlagOptions(false, evaluationContext...)
+	if err != nil {
+		return nil, err
+	}
+
+	settingName := flagName
+	if !strings.HasPrefix(settingName, AppConfigFeatureFlagKeyPrefix) {
+		settingName = AppConfigFeatureFlagKeyPrefix + flagName
+	}
+
+	val, err := e.azAppConfigSetting(appConfigUrl, settingName, label)
+	if err != nil || val == nil {
+		// val is empty in lint mode
+		return val, err
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	setting := &models.AzAppconfigSetting{}
+	if err := json.Unmarshal(data, setting); err != nil {
+		return nil, fmt.Errorf(`unable to use feature flag "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, flagName, err)
+	}
+
+	if !strings.HasPrefix(to.String(setting.ContentType), AppConfigContentTypeFeatureFlag) {
+		return nil, fmt.Errorf(`app setting "%[2]v" from appconfig instance "%[1]v" is not a feature flag (content type "%[3]v")`, appConfigUrl, settingName, to.String(setting.ContentType))
+	}
+
+	flag, err := e.newAppConfigFeatureFlag(setting, flagOptions.appConfigFeatureFlagContext)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to parse feature flag "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, flagName, err)
+	}
+
+	return transformToInterface(flag)
+}
+
+// azAppConfigFeatureFlagList fetches all feature flags matching flagFilter (eg. "*" or "beta-*") from Azure AppConfig,
+// returns map of feature flag id and feature flag
+func (e *AzureTemplateExecutor) azAppConfigFeatureFlagList(appConfigUrl string, flagFilter string, options ...map[string]interface{}) (interface{}, error) {
+	flagOptions, err := parseAppConfigFeatureFlagOptions(true, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// azure keyvault url detection
+	if val, err := e.buildAppConfigUrl(appConfigUrl); err == nil {
+		appConfigUrl = val
+	} else {
+		return nil, err
+	}
+
+	e.logger.Info(`fetching AppConfig feature flag list`, slog.String("url", appConfigUrl), slog.String("flagFilter", flagFilter), slog.String("labels", strings.Join(flagOptions.Labels, ",")))
+
+	if val, enabled := e.lintResult(); enabled {
+		return val, nil
+	}
+
+	settings, err := e.listAppConfigSettings(appConfigUrl, AppConfigFeatureFlagKeyPrefix+flagFilter, flagOptions.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := map[string]interface{}{}
+	for _, setting := range settings {
+		if !strings.HasPrefix(to.String(setting.ContentType), AppConfigContentTypeFeatureFlag) {
+			e.logger.Debug(`skipping AppConfig setting without feature flag content type`, slog.String("url", appConfigUrl), slog.String("setting", to.String(setting.Key)))
+			continue
+		}
+
+		flag, err := e.newAppConfigFeatureFlag(setting, flagOptions.appConfigFeatureFlagContext)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to parse feature flag "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, to.String(setting.Key), err)
+		}
+
+		if ret[flag.Id], err = transformToInterface(flag); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// newAppConfigFeatureFlag parses the feature flag inside setting and evaluates it for flagContext
+func (e *AzureTemplateExecutor) newAppConfigFeatureFlag(setting *models.AzAppconfigSetting, flagContext appConfigFeatureFlagContext) (*models.AzAppconfigFeatureFlag, error) {
+	value := appConfigFeatureFlagValue{}
+	if err := json.Unmarshal([]byte(to.String(setting.Value)), &value); err != nil {
+		return nil, err
+	}
+
+	ret := &models.AzAppconfigFeatureFlag{
+		Id:          value.Id,
+		Description: value.Description,
+		DisplayName: value.DisplayName,
+		Enabled:     value.Enabled,
+		Conditions: models.AzAppconfigFeatureFlagConditions{
+			RequirementType: AppConfigFeatureFlagRequirementAny,
+			ClientFilters:   []models.AzAppconfigFeatureFlagFilter{},
+		},
+		Variants:     []models.AzAppconfigFeatureFlagVariant{},
+		Allocation:   value.Allocation,
+		Key:          setting.Key,
+		Label:        setting.Label,
+		ETag:         setting.ETag,
+		LastModified: setting.LastModified,
+	}
+
+	if ret.Id == "" {
+		ret.Id = strings.TrimPrefix(to.String(setting.Key), AppConfigFeatureFlagKeyPrefix)
+	}
+
+	if value.Conditions != nil {
+		if value.Conditions.RequirementType != "" {
+			ret.Conditions.RequirementType = value.Conditions.RequirementType
+		}
+
+		for _, filter := range value.Conditions.ClientFilters {
+			ret.Conditions.ClientFilters = append(ret.Conditions.ClientFilters, models.AzAppconfigFeatureFlagFilter{
+				Name:       filter.Name,
+				Parameters: filter.Parameters,
+			})
+		}
+	}
+
+	for _, variant := range value.Variants {
+		ret.Variants = append(ret.Variants, models.AzAppconfigFeatureFlagVariant{
+			Name:               variant.Name,
+			ConfigurationValue: variant.ConfigurationValue,
+			StatusOverride:     variant.StatusOverride,
+		})
+	}
+
+	active, unsupportedFilters, err := evaluateAppConfigFeatureFlag(ret, flagContext)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(unsupportedFilters) > 0 {
+		e.logger.Warn(
+			`feature flag uses unsupported client filters, filters are treated as not satisfied`,
+			slog.String("featureFlag", ret.Id),
+			slog.String("filters", strings.Join(unsupportedFilters, ", ")),
+		)
+	}
+	ret.Active = active
+
+	return ret, nil
+}
+
+// parseAppConfigFeatureFlagOptions parses the evaluation context (eg. `dict "user" "alice" "groups" (list "beta")`),
+// labels (label fallback chain) are only allowed for lists
+func parseAppConfigFeatureFlagOptions(withLabels bool, options ...map[string]interface{}) (appConfigFeatureFlagOptions, error) {
+	ret := appConfigFeatureFlagOptions{
+		appConfigFeatureFlagContext: appConfigFeatureFlagContext{
+			Time: time.Now(),
+		},
+		Labels: []string{AppConfigNullLabel},
+	}
+
+	if len(options) > 1 {
+		return ret, fmt.Errorf(`only one options dict is allowed`)
+	}
+
+	supportedOptions := "user, groups, time"
+	if withLabels {
+		supportedOptions = "labels, " + supportedOptions
+	}
+
+	for _, option := range options {
+		for key, val := range option {
+			switch key {
+			case "labels":
+				if !withLabels {
+					return ret, fmt.Errorf(`unknown option "%v" (supported: %v)`, key, supportedOptions)
+				}
+
+				labels, err := parseAppConfigLabels(val)
+				if err != nil {
+					return ret, err
+				}
+				ret.Labels = labels
+			case "user":
+				ret.User = fmt.Sprintf("%v", val)
+			case "groups":
+				switch v := val.(type) {
+				case string:
+					ret.Groups = strings.Split(v, ",")
+				case []interface{}:
+					for _, group := range v {
+						ret.Groups = append(ret.Groups, fmt.Sprintf("%v", group))
+					}
+				case []string:
+					ret.Groups = append(ret.Groups, v...)
+				default:
+					return ret, fmt.Errorf(`option "groups" must be a list or comma separated string, got %T`, val)
+				}
+			case "time":
+				switch v := val.(type) {
+				case time.Time:
+					ret.Time = v
+				default:
+					evaluationTime, err := time.Parse(time.RFC3339, fmt.Sprintf("%v", val))
+					if err != nil {
+						return ret, fmt.Errorf(`option "time" must be a RFC3339 time (eg. 2024-01-01T00:00:00Z): %w`, err)
+					}
+					ret.Time = evaluationTime
+				}
+			default:
+				return ret, fmt.Errorf(`unknown option "%v" (supported: %v)`, key, supportedOptions)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// evaluateAppConfigFeatureFlag evaluates if feature flag is active for flagContext,
+// returns the names of client filters which are not supported (treated as not satisfied)
+func evaluateAppConfigFeatureFlag(flag *models.AzAppconfigFeatureFlag, flagContext appConfigFeatureFlagContext) (bool, []string, error) {
+	unsupportedFilters := []string{}
+
+	if !flag.Enabled {
+		return false, unsupportedFilters, nil
+	}
+
+	if len(flag.Conditions.ClientFilters) == 0 {
+		return true, unsupportedFilters, nil
+	}
+
+	requireAll := strings.EqualFold(flag.Conditions.RequirementType, AppConfigFeatureFlagRequirementAll)
+	for _, filter := range flag.Conditions.ClientFilters {
+		satisfied, supported, err := evaluateAppConfigFeatureFlagFilter(flag.Id, filter, flagContext)
+		if err != nil {
+			return false, unsupportedFilters, fmt.Errorf(`invalid client filter "%v": %w`, filter.Name, err)
+		}
+
+		if !supported {
+			unsupportedFilters = append(unsupportedFilters, filter.Name)
+		}
+
+		if requireAll && !satisfied {
+			return false, unsupportedFilters, nil
+		}
+
+		if !requireAll && satisfied {
+			return true, unsupportedFilters, nil
+		}
+	}
+
+	return requireAll, unsupportedFilters, nil
+}
+
+// evaluateAppConfigFeatureFlagFilter evaluates one client filter, returns false as second value if filter is not supported
+func evaluateAppConfigFeatureFlagFilter(flagId string, filter models.AzAppconfigFeatureFlagFilter, flagContext appConfigFeatureFlagContext) (bool, bool, error) {
+	data, err := json.Marshal(filter.Parameters)
+	if err != nil {
+		return false, true, err
+	}
+
+	switch {
+	case strings.EqualFold(filter.Name, AppConfigFeatureFlagFilterTargeting) || strings.EqualFold(filter.Name, "Targeting"):
+		parameters := appConfigTargetingParameters{}
+		if err := json.Unmarshal(data, &parameters); err != nil {
+			return false, true, err
+		}
+		audience := parameters.Audience
+
+		if slices.Contains(audience.Exclusion.Users, flagContext.User) && flagContext.User != "" {
+			return false, true, nil
+		}
+
+		for _, group := range flagContext.Groups {
+			if slices.Contains(audience.Exclusion.Groups, group) {
+				return false, true, nil
+			}
+		}
+
+		if slices.Contains(audience.Users, flagContext.User) && flagContext.User != "" {
+			return true, true, nil
+		}
+
+		for _, group := range audience.Groups {
+			if slices.Contains(flagContext.Groups, group.Name) && isAppConfigTargeted(fmt.Sprintf("%v\n%v\n%v", flagContext.User, flagId, group.Name), group.RolloutPercentage) {
+				return true, true, nil
+			}
+		}
+
+		return isAppConfigTargeted(fmt.Sprintf("%v\n%v", flagContext.User, flagId), audience.DefaultRolloutPercentage), true, nil
+	case strings.EqualFold(filter.Name, AppConfigFeatureFlagFilterTimeWindow) || strings.EqualFold(filter.Name, "TimeWindow"):
+		parameters := appConfigTimeWindowParameters{}
+		if err := json.Unmarshal(data, &parameters); err != nil {
+			return false, true, err
+		}
+
+		if parameters.Recurrence != nil {
+			// recurring time windows are not supported
+			return false, false, nil
+		}
+
+		if parameters.Start != "" {
+			start, err := parseAppConfigFeatureFlagTime(parameters.Start)
+			if err != nil {
+				return false, true, err
+			}
+
+			if flagContext.Time.Before(start) {
+				return false, true, nil
+			}
+		}
+
+		if parameters.End != "" {
+			end, err := parseAppConfigFeatureFlagTime(parameters.End)
+			if err != nil {
+				return false, true, err
+			}
+
+			if !flagContext.Time.Before(end) {
+				return false, true, nil
+			}
+		}
+
+		return true, true, nil
+	}
+
+	return false, false, nil
+}
+
+// isAppConfigTargeted checks if contextId is inside the rollout percentage (same algorithm as the Microsoft feature management libraries)
+func isAppConfigTargeted(contextId string, percentage float64) bool {
+	if percentage <= 0 {
+		return false
+	}
+
+	if percentage >= 100 {
+		return true
+	}
+
+	hash := sha256.Sum256([]byte(contextId))
+	contextMarker := binary.LittleEndian.Uint32(hash[:4])
+	contextPercentage := float64(contextMarker) / float64(math.MaxUint32) * 100
+
+	return contextPercentage < percentage
+}
+
+// parseAppConfigFeatureFlagTime parses times of time window filters (RFC1123 as written by the Azure portal or RFC3339)
+func parseAppConfigFeatureFlagTime(val string) (time.Time, error) {
+	for _, layout := range []string{time.RFC1123, time.RFC1123Z, time.RFC3339} {
+		if ret, err := time.Parse(layout, val); err == nil {
+			return ret, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf(`unable to parse time "%v" (expected RFC1123 or RFC3339)`, val)
+}
@@ -0,0 +1,153 @@
+package azuretpl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
+)
+
+func TestIsAppConfigTargeted(t *testing.T) {
+	tests := []struct {
+		name       string
+		contextId  string
+		percentage float64
+		expected   bool
+	}{
+		// context percentages: "alice\nBeta" = 88.79, "bob\nBeta" = 1.40, "carol\nBeta\nRing1" = 1.62
+		{name: "no rollout", contextId: "bob\nBeta", percentage: 0, expected: false},
+		{name: "full rollout", contextId: "alice\nBeta", percentage: 100, expected: true},
+		{name: "below percentage", contextId: "alice\nBeta", percentage: 90, expected: true},
+		{name: "above percentage", contextId: "alice\nBeta", percentage: 88, expected: false},
+		{name: "small percentage", contextId: "bob\nBeta", percentage: 1.5, expected: true},
+		{name: "group context", contextId: "carol\nBeta\nRing1", percentage: 1.6, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := isAppConfigTargeted(test.contextId, test.percentage); result != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestEvaluateAppConfigFeatureFlag(t *testing.T) {
+	targeting := models.AzAppconfigFeatureFlagFilter{
+		Name: AppConfigFeatureFlagFilterTargeting,
+		Parameters: map[string]interface{}{
+			"Audience": map[string]interface{}{
+				"Users": []interface{}{"dave"},
+				"Groups": []interface{}{
+					map[string]interface{}{"Name": "Ring0", "RolloutPercentage": 100},
+				},
+				"DefaultRolloutPercentage": 50,
+				"Exclusion": map[string]interface{}{
+					"Users":  []interface{}{"eve"},
+					"Groups": []interface{}{"Blocked"},
+				},
+			},
+		},
+	}
+
+	timeWindow := models.AzAppconfigFeatureFlagFilter{
+		Name: AppConfigFeatureFlagFilterTimeWindow,
+		Parameters: map[string]interface{}{
+			"Start": "Mon, 01 Jan 2024 00:00:00 GMT",
+			"End":   "2024-02-01T00:00:00Z",
+		},
+	}
+
+	recurringTimeWindow := models.AzAppconfigFeatureFlagFilter{
+		Name: AppConfigFeatureFlagFilterTimeWindow,
+		Parameters: map[string]interface{}{
+			"Start":      "Mon, 01 Jan 2024 00:00:00 GMT",
+			"Recurrence": map[string]interface{}{"Pattern": map[string]interface{}{"Type": "Daily"}},
+		},
+	}
+
+	inWindow := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	afterWindow := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                string
+		enabled             bool
+		requirementType     string
+		filters             []models.AzAppconfigFeatureFlagFilter
+		context             appConfigFeatureFlagContext
+		expected            bool
+		expectedUnsupported int
+	}{
+		{name: "disabled", enabled: false, expected: false},
+		{name: "enabled without filters", enabled: true, expected: true},
+		{name: "targeted user", enabled: true, filters: []models.AzAppconfigFeatureFlagFilter{targeting}, context: appConfigFeatureFlagContext{User: "dave"}, expected: true},
+		{name: "excluded user", enabled: true, filters: []models.AzAppconfigFeatureFlagFilter{targeting}, context: appConfigFeatureFlagContext{User: "eve", Groups: []string{"Ring0"}}, expected: false},
+		{name: "excluded group", enabled: true, filters: []models.AzAppconfigFeatureFlagFilter{targeting}, context: appConfigFeatureFlagContext{User: "dave", Groups: []string{"Blocked"}}, expected: false},
+		{name: "targeted group", enabled: true, filters: []models.AzAppconfigFeatureFlagFilter{targeting}, context: appConfigFeatureFlagContext{User: "frank", Groups: []string{"Ring0"}}, expected: true},
+		{name: "inside time window", enabled: true, filters: []models.AzAppconfigFeatureFlagFilter{timeWindow}, context: appConfigFeatureFlagContext{Time: inWindow}, expected: true},
+		{name: "end of time window", enabled: true, filters: []models.AzAppconfigFeatureFlagFilter{timeWindow}, context: appConfigFeatureFlagContext{Time: afterWindow}, expected: false},
+		{name: "recurring time window is unsupported", enabled: true, filters: []models.AzAppconfigFeatureFlagFilter{recurringTimeWindow}, context: appConfigFeatureFlagContext{Time: inWindow}, expected: false, expectedUnsupported: 1},
+		{
+			name:            "any filter",
+			enabled:         true,
+			requirementType: AppConfigFeatureFlagRequirementAny,
+			filters:         []models.AzAppconfigFeatureFlagFilter{timeWindow, targeting},
+			context:         appConfigFeatureFlagContext{User: "dave", Time: afterWindow},
+			expected:        true,
+		},
+		{
+			name:            "all filters",
+			enabled:         true,
+			requirementType: AppConfigFeatureFlagRequirementAll,
+			filters:         []models.AzAppconfigFeatureFlagFilter{targeting, timeWindow},
+			context:         appConfigFeatureFlagContext{User: "dave", Time: afterWindow},
+			expected:        false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flag := &models.AzAppconfigFeatureFlag{
+				Id:      "Beta",
+				Enabled: test.enabled,
+				Conditions: models.AzAppconfigFeatureFlagConditions{
+					RequirementType: test.requirementType,
+					ClientFilters:   test.filters,
+				},
+			}
+
+			result, unsupported, err := evaluateAppConfigFeatureFlag(flag, test.context)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+
+			if len(unsupported) != test.expectedUnsupported {
+				t.Errorf("expected %v unsupported filters, got %v", test.expectedUnsupported, unsupported)
+			}
+		})
+	}
+}
+
+func TestParseAppConfigFeatureFlagTime(t *testing.T) {
+	expected := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, val := range []string{"Mon, 01 Jan 2024 00:00:00 GMT", "Mon, 01 Jan 2024 01:00:00 +0100", "2024-01-01T00:00:00Z"} {
+		result, err := parseAppConfigFeatureFlagTime(val)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", val, err)
+			continue
+		}
+
+		if !result.Equal(expected) {
+			t.Errorf("expected %v for %q, got %v", expected, val, result)
+		}
+	}
+
+	if _, err := parseAppConfigFeatureFlagTime("2024-01-01"); err == nil {
+		t.Errorf("expected error for invalid time")
+	}
+}
@@ -5,11 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"mime"
 	"net/url"
+	"regexp"
+	"sort"
 	"strings"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/data/azappconfig"
-	"github.com/webdevops/go-common/azuresdk/cloudconfig"
 	"github.com/webdevops/go-common/utils/to"
 
 	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
@@ -24,16 +25,13 @@ func (e *AzureTemplateExecutor) buildAppConfigUrl(appConfigUrl string) (string,
 
 	// vault url generation (if only vault name is specified)
 	if !strings.HasPrefix(strings.ToLower(appConfigUrl), "https://") {
-		switch cloudName := e.azureClient().GetCloudName(); cloudName {
-		case cloudconfig.AzurePublicCloud:
-			appConfigUrl = fmt.Sprintf(`https://%s.azconfig.io`, appConfigUrl)
-		case cloudconfig.AzureChinaCloud:
-			appConfigUrl = fmt.Sprintf(`https://%s.azconfig.azure.cn`, appConfigUrl)
-		case cloudconfig.AzureGovernmentCloud:
-			appConfigUrl = fmt.Sprintf(`https://%s.azconfig.azure.us`, appConfigUrl)
-		default:
-			return appConfigUrl, fmt.Errorf(`cannot build Azure AppConfig url for "%s" and Azure cloud "%s", please use full url`, appConfigUrl, cloudName)
+		url, err := e.buildCloudHostUrl(appConfigUrl, func(endpoints CloudEndpoints) string {
+			return endpoints.AppConfigurationDNSSuffix
+		}, "appConfigurationDNSSuffix")
+		if err != nil {
+			return appConfigUrl, err
 		}
+		appConfigUrl = url
 	}
 
 	// improve caching by removing trailing slash
@@ -57,60 +55,495 @@ func (e *AzureTemplateExecutor) azAppConfigSetting(appConfigUrl string, settingN
 		return val, nil
 	}
 	cacheKey := generateCacheKey(`azAppConfigSetting`, appConfigUrl, settingName, label)
-	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		client, err := azappconfig.NewClient(appConfigUrl, e.azureClient().GetCred(), nil)
+	ret, err := e.cacheResult(cacheKey, func() (interface{}, error) {
+		appConfigValue, err := e.providers.AppConfig.GetSetting(e.ctx, appConfigUrl, settingName, label)
 		if err != nil {
-			return nil, fmt.Errorf(`failed to create appconfig client for instance "%v": %w`, appConfigUrl, err)
+			return nil, fmt.Errorf(`unable to fetch app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
 		}
 
-		options := azappconfig.GetSettingOptions{}
-		if label != "" {
-			options.Label = to.StringPtr(label)
+		setting := models.NewAzAppconfigSettingFromReponse(appConfigValue)
+		if err := e.resolveAppConfigKeyVaultReference(appConfigUrl, setting); err != nil {
+			return nil, err
 		}
 
-		appConfigValue, err := client.GetSetting(e.ctx, settingName, &options)
+		return transformToInterface(setting)
+	})
+	if err != nil {
+		return ret, err
+	}
+
+	if e.prefetchOnly {
+		return ret, nil
+	}
+
+	// lock file, summary and policy are checked for every call as results could be cached
+	if err := e.checkAppConfigSettingLock(appConfigUrl, settingName, label, ret); err != nil {
+		return nil, err
+	}
+
+	setting, err := parseAppConfigSetting(ret)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to use app setting "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
+	}
+
+	if err := e.recordAppConfigKeyVaultReference(`azAppConfigSetting`, appConfigUrl, setting); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+var (
+	// appConfigKeyVaultSecretNameRegexp matches valid Azure KeyVault secret names
+	appConfigKeyVaultSecretNameRegexp = regexp.MustCompile(`^[0-9a-zA-Z-]{1,127}$`)
+
+	// appConfigKeyVaultSecretVersionRegexp matches valid Azure KeyVault secret versions
+	appConfigKeyVaultSecretVersionRegexp = regexp.MustCompile(`^[0-9a-zA-Z]{1,64}$`)
+)
+
+// isAppConfigKeyVaultReference returns true if contentType is the content type of KeyVault references (parameters like charset are ignored)
+func isAppConfigKeyVaultReference(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(mediaType, AppConfigContentTypeKeyVaultRef)
+}
+
+// resolveAppConfigKeyVaultReference replaces the value of KeyVault references with the value of the referenced secret
+// and sets the metadata of the referenced secret
+func (e *AzureTemplateExecutor) resolveAppConfigKeyVaultReference(appConfigUrl string, setting *models.AzAppconfigSetting) error {
+	settingName := to.String(setting.Key)
+
+	if !isAppConfigKeyVaultReference(to.String(setting.ContentType)) {
+		return nil
+	}
+
+	keyVaultResult := struct {
+		Uri string
+	}{}
+
+	data := to.String(setting.Value)
+	if err := json.Unmarshal([]byte(data), &keyVaultResult); err != nil {
+		return fmt.Errorf(`unable to parse keyvault reference from app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
+	}
+
+	reference, err := parseAppConfigKeyVaultReference(keyVaultResult.Uri)
+	if err != nil {
+		return fmt.Errorf(`unable to parse keyvault reference from app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
+	}
+
+	if err := e.checkAppConfigKeyVaultReferenceHost(reference); err != nil {
+		return fmt.Errorf(`unable to use keyvault reference from app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
+	}
+
+	e.logger.Info(`resolving AppConfig KeyVault reference`, slog.String("url", appConfigUrl), slog.String("setting", settingName), slog.String("keyvault", reference.VaultUrl), slog.String("secret", reference.SecretName))
+
+	opts := []string{}
+	if reference.VersionPinned {
+		opts = append(opts, reference.Version)
+	}
+
+	// policy is checked by the calling template function (see recordAppConfigKeyVaultReference)
+	secretData, err := e.fetchKeyVaultSecret(reference.VaultUrl, reference.SecretName, opts...)
+	if err != nil {
+		return fmt.Errorf(`unable to fetch keyvault reference from app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
+	}
+
+	secret, err := parseKeyVaultSecretListItem(secretData)
+	if err != nil {
+		return fmt.Errorf(`unable to use keyvault reference from app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
+	}
+
+	if reference.VersionPinned && !strings.EqualFold(secret.Version, reference.Version) {
+		return fmt.Errorf(`keyvault reference from app setting value "%[2]v" from appconfig instance "%[1]v" requested version "%[3]v" but got version "%[4]v"`, appConfigUrl, settingName, reference.Version, secret.Version)
+	}
+
+	reference.Version = secret.Version
+	reference.ContentType = to.String(secret.ContentType)
+	if secret.Attributes != nil {
+		reference.Created = secret.Attributes.Created
+		reference.Expires = secret.Attributes.Expires
+	}
+
+	setting.Value = to.StringPtr(to.String(secret.Value))
+	setting.KeyVaultReference = reference
+
+	return nil
+}
+
+// recordAppConfigKeyVaultReference adds the secret referenced by setting to the summary and checks it against the expiry policy,
+// function is the template function which fetched the setting
+func (e *AzureTemplateExecutor) recordAppConfigKeyVaultReference(function, appConfigUrl string, setting *models.AzAppconfigSetting) error {
+	if setting.KeyVaultReference == nil || e.prefetchOnly {
+		return nil
+	}
+
+	e.addSummaryAppConfigKeyVaultReference(appConfigUrl, setting)
+
+	return e.checkAppConfigKeyVaultReferencePolicy(function, appConfigUrl, setting)
+}
+
+// checkAppConfigKeyVaultReferenceHost checks the vault host of a KeyVault reference against the allowed hosts (see --keyvault.reference.allowed-host),
+// by default only vaults of the current Azure cloud are allowed
+func (e *AzureTemplateExecutor) checkAppConfigKeyVaultReferenceHost(reference *models.AzAppconfigKeyVaultReference) error {
+	vaultUrl, err := url.Parse(reference.VaultUrl)
+	if err != nil {
+		return err
+	}
+	host := strings.ToLower(vaultUrl.Hostname())
+
+	allowedHosts := e.opts.Keyvault.ReferenceAllowedHosts
+	if len(allowedHosts) == 0 {
+		endpoints, err := e.cloudEndpoints()
 		if err != nil {
-			return nil, fmt.Errorf(`unable to fetch app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
+			return err
 		}
 
-		switch to.String(appConfigValue.ContentType) {
-		case "application/vnd.microsoft.appconfig.keyvaultref+json;charset=utf-8":
-			keyVaultResult := struct {
-				Uri string
-			}{}
+		dnsSuffix := strings.Trim(endpoints.KeyVaultDNSSuffix, ".")
+		if dnsSuffix == "" {
+			return fmt.Errorf(`cannot validate keyvault host "%s", Azure cloud "%s" has no keyVaultDNSSuffix, please set it in the cloud config (--cloud.config) or set allowed hosts (--keyvault.reference.allowed-host)`, host, endpoints.Name)
+		}
+		allowedHosts = []string{"*." + dnsSuffix}
+	}
 
-			data := to.String(appConfigValue.Value)
-			if err := json.Unmarshal([]byte(data), &keyVaultResult); err != nil {
-				return nil, fmt.Errorf(`unable to parse keyvault reference from app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
+	for _, allowedHost := range allowedHosts {
+		allowedHost = strings.ToLower(strings.TrimSpace(allowedHost))
+		if suffix, isWildcard := strings.CutPrefix(allowedHost, "*."); isWildcard {
+			// wildcard only matches the vault name
+			if vaultName, found := strings.CutSuffix(host, "."+suffix); found && vaultName != "" && !strings.Contains(vaultName, ".") {
+				return nil
 			}
+		} else if host == allowedHost {
+			return nil
+		}
+	}
 
-			if keyVaultResult.Uri == "" {
-				return nil, fmt.Errorf(`unable to parse keyvault reference from app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, errors.New("keyvault uri is empty"))
-			}
+	return fmt.Errorf(`keyvault host "%v" is not allowed (allowed: %v), see --keyvault.reference.allowed-host`, host, strings.Join(allowedHosts, ", "))
+}
 
-			keyVaultRefUrl, err := url.Parse(keyVaultResult.Uri)
-			if err != nil {
-				return nil, fmt.Errorf(`unable to parse keyvault reference from app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
-			}
+// parseAppConfigKeyVaultReference parses and validates the secret uri of an AppConfig KeyVault reference
+// (https://{vault}/secrets/{name}[/{version}]), version is empty if not set in uri
+func parseAppConfigKeyVaultReference(uri string) (*models.AzAppconfigKeyVaultReference, error) {
+	if uri == "" {
+		return nil, errors.New("keyvault uri is empty")
+	}
 
-			vaultUrl := fmt.Sprintf(`https://%s`, keyVaultRefUrl.Host)
-			vaultSecretPathParts := strings.Split(strings.TrimPrefix(keyVaultRefUrl.Path, "/"), "/")
+	keyVaultRefUrl, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf(`keyvault uri "%v" is invalid: %w`, uri, err)
+	}
 
-			secretName := vaultSecretPathParts[1]
-			secretVersion := ""
-			if len(vaultSecretPathParts) >= 3 {
-				secretVersion = vaultSecretPathParts[2]
-			}
+	if !strings.EqualFold(keyVaultRefUrl.Scheme, "https") {
+		return nil, fmt.Errorf(`keyvault uri "%v" must use https`, uri)
+	}
 
-			secret, err := e.azKeyVaultSecret(vaultUrl, secretName, secretVersion)
-			if err != nil {
-				return nil, fmt.Errorf(`unable to fetch keyvault reference from app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
-			}
+	if keyVaultRefUrl.Hostname() == "" || keyVaultRefUrl.User != nil {
+		return nil, fmt.Errorf(`keyvault uri "%v" has no valid vault host`, uri)
+	}
+
+	if keyVaultRefUrl.RawQuery != "" || keyVaultRefUrl.Fragment != "" {
+		return nil, fmt.Errorf(`keyvault uri "%v" must not contain query or fragment`, uri)
+	}
+
+	vaultSecretPathParts := strings.Split(strings.Trim(keyVaultRefUrl.Path, "/"), "/")
+	if len(vaultSecretPathParts) < 2 || len(vaultSecretPathParts) > 3 || !strings.EqualFold(vaultSecretPathParts[0], "secrets") {
+		return nil, fmt.Errorf(`keyvault uri "%v" is not a secret uri (expected https://{vault}/secrets/{name}[/{version}])`, uri)
+	}
+
+	if !appConfigKeyVaultSecretNameRegexp.MatchString(vaultSecretPathParts[1]) {
+		return nil, fmt.Errorf(`keyvault uri "%v" contains invalid secret name "%v"`, uri, vaultSecretPathParts[1])
+	}
 
-			secretMap := secret.(map[string]interface{})
-			appConfigValue.Value = to.StringPtr(secretMap["value"].(string))
+	ret := &models.AzAppconfigKeyVaultReference{
+		Uri:        uri,
+		VaultUrl:   fmt.Sprintf(`https://%s`, strings.ToLower(keyVaultRefUrl.Host)),
+		SecretName: vaultSecretPathParts[1],
+	}
+
+	if len(vaultSecretPathParts) == 3 {
+		if !appConfigKeyVaultSecretVersionRegexp.MatchString(vaultSecretPathParts[2]) {
+			return nil, fmt.Errorf(`keyvault uri "%v" contains invalid secret version "%v"`, uri, vaultSecretPathParts[2])
 		}
+		ret.Version = vaultSecretPathParts[2]
+		ret.VersionPinned = true
+	}
+
+	return ret, nil
+}
+
+const (
+	// AppConfigNullLabel is the label filter for settings without label
+	AppConfigNullLabel = `\0`
+)
+
+type (
+	// appConfigSettingListOptions are the options of azAppConfigSettingList
+	appConfigSettingListOptions struct {
+		// Labels is the label fallback chain, the first label found for a key is used
+		Labels []string
+
+		// Separator splits keys into a nested map (flat map if empty)
+		Separator string
 
-		return transformToInterface(models.NewAzAppconfigSettingFromReponse(appConfigValue))
+		// TrimPrefix is removed from all keys
+		TrimPrefix string
+	}
+)
+
+// azAppConfigSettingList fetches all settings matching keyFilter (eg. "myapp:*") from Azure AppConfig,
+// returns map of key and value (nested if separator is set)
+func (e *AzureTemplateExecutor) azAppConfigSettingList(appConfigUrl string, keyFilter string, options ...map[string]interface{}) (interface{}, error) {
+	listOptions, err := parseAppConfigSettingListOptions(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// azure keyvault url detection
+	if val, err := e.buildAppConfigUrl(appConfigUrl); err == nil {
+		appConfigUrl = val
+	} else {
+		return nil, err
+	}
+
+	e.logger.Info(`fetching AppConfig setting list`, slog.String("url", appConfigUrl), slog.String("keyFilter", keyFilter), slog.String("labels", strings.Join(listOptions.Labels, ",")))
+
+	if val, enabled := e.lintResult(); enabled {
+		return val, nil
+	}
+
+	settings, err := e.listAppConfigSettings(appConfigUrl, keyFilter, listOptions.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	// keyvault references are resolved for every call as results could be cached
+	ret := map[string]interface{}{}
+	for _, setting := range settings {
+		if err := e.resolveAppConfigKeyVaultReference(appConfigUrl, setting); err != nil {
+			return nil, err
+		}
+
+		if err := e.recordAppConfigKeyVaultReference(`azAppConfigSettingList`, appConfigUrl, setting); err != nil {
+			return nil, err
+		}
+
+		if err := listOptions.setValue(ret, to.String(setting.Key), to.String(setting.Value)); err != nil {
+			return nil, fmt.Errorf(`unable to use app settings "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, keyFilter, err)
+		}
+	}
+
+	return ret, nil
+}
+
+// listAppConfigSettings fetches all settings matching keyFilter and labels (label fallback chain, first found label is used per key),
+// returns settings sorted by key
+func (e *AzureTemplateExecutor) listAppConfigSettings(appConfigUrl, keyFilter string, labels []string) ([]*models.AzAppconfigSetting, error) {
+	labelFilter := strings.Join(labels, ",")
+
+	cacheKey := generateCacheKey(`azAppConfigSettingList`, appConfigUrl, keyFilter, labelFilter)
+	list, err := e.cacheResult(cacheKey, func() (interface{}, error) {
+		result, err := e.providers.AppConfig.ListSettings(e.ctx, appConfigUrl, keyFilter, labelFilter)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to list app settings "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, keyFilter, err)
+		}
+
+		ret := []*models.AzAppconfigSetting{}
+		for _, setting := range result {
+			ret = append(ret, models.NewAzAppconfigSetting(setting))
+		}
+
+		return transformToInterface(ret)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := parseAppConfigSettingList(list)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to use app settings "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, keyFilter, err)
+	}
+
+	selectedSettings := selectAppConfigSettings(settings, labels)
+	keys := make([]string, 0, len(selectedSettings))
+	for key := range selectedSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ret := make([]*models.AzAppconfigSetting, 0, len(keys))
+	for _, key := range keys {
+		setting := selectedSettings[key]
+		settingData, err := transformToInterface(setting)
+		if err != nil {
+			return nil, err
+		}
+
+		// lock file is checked for every call as results could be cached
+		if err := e.checkAppConfigSettingLock(appConfigUrl, key, to.String(setting.Label), settingData); err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, setting)
+	}
+
+	return ret, nil
+}
+
+// parseAppConfigSetting converts the cached result of azAppConfigSetting back to a setting
+func parseAppConfigSetting(val interface{}) (*models.AzAppconfigSetting, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.AzAppconfigSetting{}
+	if err := json.Unmarshal(data, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// parseAppConfigSettingList converts the cached result of azAppConfigSettingList back to settings
+func parseAppConfigSettingList(val interface{}) ([]*models.AzAppconfigSetting, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*models.AzAppconfigSetting{}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// parseAppConfigSettingListOptions parses the options of azAppConfigSettingList (eg. `dict "labels" (list "prod" "\\0") "separator" ":"`)
+func parseAppConfigSettingListOptions(options ...map[string]interface{}) (appConfigSettingListOptions, error) {
+	ret := appConfigSettingListOptions{
+		// settings without label (same as azAppConfigSetting with empty label)
+		Labels: []string{AppConfigNullLabel},
+	}
+	if len(options) > 1 {
+		return ret, fmt.Errorf(`only one options dict is allowed`)
+	}
+
+	for _, option := range options {
+		for key, val := range option {
+			switch key {
+			case "labels":
+				labels, err := parseAppConfigLabels(val)
+				if err != nil {
+					return ret, err
+				}
+				ret.Labels = labels
+			case "separator":
+				ret.Separator = fmt.Sprintf("%v", val)
+			case "trimPrefix":
+				ret.TrimPrefix = fmt.Sprintf("%v", val)
+			default:
+				return ret, fmt.Errorf(`unknown option "%v" (supported: labels, separator, trimPrefix)`, key)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// parseAppConfigLabels parses the label fallback chain (list or comma separated string), empty labels are settings without label
+func parseAppConfigLabels(val interface{}) ([]string, error) {
+	ret := []string{}
+	switch v := val.(type) {
+	case string:
+		ret = strings.Split(v, ",")
+	case []interface{}:
+		for _, label := range v {
+			ret = append(ret, fmt.Sprintf("%v", label))
+		}
+	case []string:
+		ret = append(ret, v...)
+	default:
+		return nil, fmt.Errorf(`option "labels" must be a list or comma separated string, got %T`, val)
+	}
+
+	for i, label := range ret {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			label = AppConfigNullLabel
+		}
+		ret[i] = label
+	}
+
+	if len(ret) == 0 {
+		ret = []string{AppConfigNullLabel}
+	}
+
+	return ret, nil
+}
+
+// selectAppConfigSettings returns the setting of the first label in the fallback chain for each key
+func selectAppConfigSettings(settings []*models.AzAppconfigSetting, labels []string) map[string]*models.AzAppconfigSetting {
+	labelPriority := map[string]int{}
+	for i, label := range labels {
+		if _, exists := labelPriority[label]; !exists {
+			labelPriority[label] = i
+		}
+	}
+
+	priority := func(setting *models.AzAppconfigSetting) int {
+		label := to.String(setting.Label)
+		if label == "" {
+			label = AppConfigNullLabel
+		}
+
+		if val, ok := labelPriority[label]; ok {
+			return val
+		}
+		// label filter could contain wildcards (eg. "prod-*")
+		return len(labels)
+	}
+
+	ret := map[string]*models.AzAppconfigSetting{}
+	for _, setting := range settings {
+		key := to.String(setting.Key)
+		if current, exists := ret[key]; exists && priority(current) <= priority(setting) {
+			continue
+		}
+		ret[key] = setting
+	}
+
+	return ret
+}
+
+// setValue sets value of key inside ret, keys are split into nested maps if separator is set
+func (o appConfigSettingListOptions) setValue(ret map[string]interface{}, key string, value string) error {
+	key = strings.TrimPrefix(key, o.TrimPrefix)
+
+	if o.Separator == "" {
+		ret[key] = value
+		return nil
+	}
+
+	parts := strings.Split(key, o.Separator)
+	current := ret
+	for i, part := range parts[:len(parts)-1] {
+		switch v := current[part].(type) {
+		case nil:
+			child := map[string]interface{}{}
+			current[part] = child
+			current = child
+		case map[string]interface{}:
+			current = v
+		default:
+			return fmt.Errorf(`key "%v" conflicts with value of key "%v"`, key, strings.Join(parts[:i+1], o.Separator))
+		}
+	}
+
+	last := parts[len(parts)-1]
+	if _, isMap := current[last].(map[string]interface{}); isMap {
+		return fmt.Errorf(`key "%v" conflicts with nested keys "%v%v*"`, key, key, o.Separator)
+	}
+	current[last] = value
+
+	return nil
 }
@@ -0,0 +1,140 @@
+package azuretpl
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azappconfig"
+	"github.com/webdevops/go-common/utils/to"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
+)
+
+// azAppConfigSnapshotSettingList fetches all settings of a snapshot from Azure AppConfig (resolves keyvault references)
+func (e *AzureTemplateExecutor) azAppConfigSnapshotSettingList(appConfigUrl string, snapshotName string) (interface{}, error) {
+	// azure keyvault url detection
+	if val, err := e.buildAppConfigUrl(appConfigUrl); err == nil {
+		appConfigUrl = val
+	} else {
+		return nil, err
+	}
+
+	e.logger.Info(`fetching AppConfig snapshot settings`, slog.String("url", appConfigUrl), slog.String("snapshot", snapshotName))
+
+	if val, enabled := e.lintResult(); enabled {
+		return val, nil
+	}
+
+	settings, err := e.listAppConfigSnapshotSettings(appConfigUrl, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+
+	// keyvault references are resolved for every call as results could be cached
+	for _, setting := range settings {
+		if err := e.resolveAppConfigKeyVaultReference(appConfigUrl, setting); err != nil {
+			return nil, err
+		}
+
+		if err := e.recordAppConfigKeyVaultReference(`azAppConfigSnapshotSettingList`, appConfigUrl, setting); err != nil {
+			return nil, err
+		}
+	}
+
+	return transformToInterface(settings)
+}
+
+// azAppConfigSnapshotSetting fetches one setting of a snapshot from Azure AppConfig (resolves keyvault references)
+func (e *AzureTemplateExecutor) azAppConfigSnapshotSetting(appConfigUrl string, snapshotName string, settingName string, label string) (interface{}, error) {
+	// azure keyvault url detection
+	if val, err := e.buildAppConfigUrl(appConfigUrl); err == nil {
+		appConfigUrl = val
+	} else {
+		return nil, err
+	}
+
+	e.logger.Info(`fetching AppConfig snapshot setting`, slog.String("url", appConfigUrl), slog.String("snapshot", snapshotName), slog.String("setting", settingName))
+
+	if val, enabled := e.lintResult(); enabled {
+		return val, nil
+	}
+
+	// all settings of a snapshot are fetched once and cached
+	settings, err := e.listAppConfigSnapshotSettings(appConfigUrl, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, setting := range settings {
+		if to.String(setting.Key) != settingName || to.String(setting.Label) != label {
+			continue
+		}
+
+		if err := e.resolveAppConfigKeyVaultReference(appConfigUrl, setting); err != nil {
+			return nil, err
+		}
+
+		if err := e.recordAppConfigKeyVaultReference(`azAppConfigSnapshotSetting`, appConfigUrl, setting); err != nil {
+			return nil, err
+		}
+
+		return transformToInterface(setting)
+	}
+
+	return nil, fmt.Errorf(`app setting "%[3]v" (label "%[4]v") not found in snapshot "%[2]v" from appconfig instance "%[1]v"`, appConfigUrl, snapshotName, settingName, label)
+}
+
+// listAppConfigSnapshotSettings fetches all settings of a snapshot, returns settings sorted by key and label
+func (e *AzureTemplateExecutor) listAppConfigSnapshotSettings(appConfigUrl, snapshotName string) ([]*models.AzAppconfigSetting, error) {
+	cacheKey := generateCacheKey(`azAppConfigSnapshotSettingList`, appConfigUrl, snapshotName)
+	list, err := e.cacheResult(cacheKey, func() (interface{}, error) {
+		snapshot, err := e.providers.AppConfig.GetSnapshot(e.ctx, appConfigUrl, snapshotName)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to fetch snapshot "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, snapshotName, err)
+		}
+
+		// archived snapshots are still readable until they expire
+		var status azappconfig.SnapshotStatus
+		if snapshot.Status != nil {
+			status = *snapshot.Status
+		}
+
+		switch status {
+		case azappconfig.SnapshotStatusReady:
+		case azappconfig.SnapshotStatusArchived:
+			e.logger.Warn(`AppConfig snapshot is archived`, slog.String("url", appConfigUrl), slog.String("snapshot", snapshotName), slog.Any("expires", snapshot.Expires))
+		default:
+			return nil, fmt.Errorf(`snapshot "%[2]v" from appconfig instance "%[1]v" is not ready (status "%[3]v")`, appConfigUrl, snapshotName, status)
+		}
+
+		result, err := e.providers.AppConfig.ListSnapshotSettings(e.ctx, appConfigUrl, snapshotName)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to list app settings of snapshot "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, snapshotName, err)
+		}
+
+		ret := []*models.AzAppconfigSetting{}
+		for _, setting := range result {
+			ret = append(ret, models.NewAzAppconfigSetting(setting))
+		}
+
+		sort.SliceStable(ret, func(i, j int) bool {
+			if to.String(ret[i].Key) != to.String(ret[j].Key) {
+				return to.String(ret[i].Key) < to.String(ret[j].Key)
+			}
+			return to.String(ret[i].Label) < to.String(ret[j].Label)
+		})
+
+		return transformToInterface(ret)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := parseAppConfigSettingList(list)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to use app settings of snapshot "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, snapshotName, err)
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,84 @@
+package azuretpl
+
+import (
+	"testing"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
+)
+
+func TestParseAppConfigKeyVaultReference(t *testing.T) {
+	tests := []struct {
+		uri             string
+		expectedVault   string
+		expectedSecret  string
+		expectedVersion string
+		expectedErr     bool
+	}{
+		{uri: "https://myvault.vault.azure.net/secrets/password", expectedVault: "https://myvault.vault.azure.net", expectedSecret: "password"},
+		{uri: "https://MyVault.vault.azure.net/secrets/password/0123abcd", expectedVault: "https://myvault.vault.azure.net", expectedSecret: "password", expectedVersion: "0123abcd"},
+		{uri: "https://myvault.vault.azure.net:443/secrets/password/", expectedVault: "https://myvault.vault.azure.net:443", expectedSecret: "password"},
+		{uri: "", expectedErr: true},
+		{uri: "http://myvault.vault.azure.net/secrets/password", expectedErr: true},
+		{uri: "https://user@myvault.vault.azure.net/secrets/password", expectedErr: true},
+		{uri: "https://myvault.vault.azure.net/secrets/password?api-version=7.4", expectedErr: true},
+		{uri: "https://myvault.vault.azure.net/keys/key", expectedErr: true},
+		{uri: "https://myvault.vault.azure.net/secrets", expectedErr: true},
+		{uri: "https://myvault.vault.azure.net/secrets/password/version/extra", expectedErr: true},
+		{uri: "https://myvault.vault.azure.net/secrets/pass_word", expectedErr: true},
+		{uri: "https://myvault.vault.azure.net/secrets/password/v-1", expectedErr: true},
+		{uri: "https:///secrets/password", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.uri, func(t *testing.T) {
+			result, err := parseAppConfigKeyVaultReference(test.uri)
+			if test.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.VaultUrl != test.expectedVault || result.SecretName != test.expectedSecret || result.Version != test.expectedVersion {
+				t.Errorf("expected %v %v %v, got %v %v %v", test.expectedVault, test.expectedSecret, test.expectedVersion, result.VaultUrl, result.SecretName, result.Version)
+			}
+
+			if result.VersionPinned != (test.expectedVersion != "") {
+				t.Errorf("unexpected pinned version: %v", result.VersionPinned)
+			}
+		})
+	}
+}
+
+func TestCheckAppConfigKeyVaultReferenceHost(t *testing.T) {
+	allowedHosts := []string{"*.vault.azure.net", "shared.vault.example.com"}
+
+	tests := []struct {
+		vaultUrl string
+		allowed  bool
+	}{
+		{vaultUrl: "https://myvault.vault.azure.net", allowed: true},
+		{vaultUrl: "https://myvault.vault.azure.net:443", allowed: true},
+		{vaultUrl: "https://shared.vault.example.com", allowed: true},
+		{vaultUrl: "https://other.vault.example.com", allowed: false},
+		{vaultUrl: "https://vault.azure.net", allowed: false},
+		{vaultUrl: "https://sub.myvault.vault.azure.net", allowed: false},
+		{vaultUrl: "https://myvault.vault.azure.net.example.com", allowed: false},
+	}
+
+	e := &AzureTemplateExecutor{}
+	e.opts.Keyvault.ReferenceAllowedHosts = allowedHosts
+
+	for _, test := range tests {
+		t.Run(test.vaultUrl, func(t *testing.T) {
+			err := e.checkAppConfigKeyVaultReferenceHost(&models.AzAppconfigKeyVaultReference{VaultUrl: test.vaultUrl})
+			if (err == nil) != test.allowed {
+				t.Errorf("expected allowed=%v, got error %v", test.allowed, err)
+			}
+		})
+	}
+}
@@ -3,9 +3,6 @@ package azuretpl
 import (
 	"fmt"
 	"log/slog"
-
-	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/eventhub/armeventhub"
-	"github.com/webdevops/go-common/azuresdk/armclient"
 )
 
 // azEventHubListByNamespace fetches list of Azure EventHubs by Namespace
@@ -18,26 +15,9 @@ func (e *AzureTemplateExecutor) azEventHubListByNamespace(resourceID string) (in
 
 	cacheKey := generateCacheKey(`azEventHubListByNamespace`, resourceID)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		resourceInfo, err := armclient.ParseResourceId(resourceID)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to parse resourceID "%v": %w`, resourceID, err)
-		}
-
-		client, err := armeventhub.NewEventHubsClient(resourceInfo.Subscription, e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
+		ret, err := e.providers.Resource.ListEventHubsByNamespace(e.ctx, resourceID)
 		if err != nil {
-			return nil, fmt.Errorf(`failed to create EventHubsClient "%v": %w`, resourceID, err)
-		}
-
-		pager := client.NewListByNamespacePager(resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
-
-		ret := []*armeventhub.Eventhub{}
-		for pager.More() {
-			result, err := pager.NextPage(e.ctx)
-			if err != nil {
-				return nil, err
-			}
-
-			ret = append(ret, result.Value...)
+			return nil, fmt.Errorf(`failed to fetch EventHub list for namespace "%v": %w`, resourceID, err)
 		}
 
 		return transformToInterface(ret)
@@ -0,0 +1,196 @@
+package azuretpl
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/webdevops/go-common/utils/to"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
+)
+
+// azKeyVaultCertificate fetches certificate (without private key) from Azure KeyVault
+func (e *AzureTemplateExecutor) azKeyVaultCertificate(vaultUrl string, certificateName string, opts ...string) (interface{}, error) {
+	return e.fetchKeyVaultCertificate(`azKeyVaultCertificate`, vaultUrl, certificateName, opts, false)
+}
+
+// azKeyVaultCertificateWithKey fetches certificate with chain and private key (using the backing secret) from Azure KeyVault
+func (e *AzureTemplateExecutor) azKeyVaultCertificateWithKey(vaultUrl string, certificateName string, opts ...string) (interface{}, error) {
+	return e.fetchKeyVaultCertificate(`azKeyVaultCertificateWithKey`, vaultUrl, certificateName, opts, true)
+}
+
+func (e *AzureTemplateExecutor) fetchKeyVaultCertificate(funcName, vaultUrl, certificateName string, opts []string, withPrivateKey bool) (interface{}, error) {
+	// azure keyvault url detection
+	if val, err := e.buildAzKeyVaulUrl(vaultUrl); err == nil {
+		vaultUrl = val
+	} else {
+		return nil, err
+	}
+
+	e.logger.Info(`fetching Azure KeyVault certificate`, slog.String("keyvault", vaultUrl), slog.String("certificate", certificateName), slog.Bool("privateKey", withPrivateKey))
+
+	if val, enabled := e.lintResult(); enabled {
+		return val, nil
+	}
+	cacheKey := generateCacheKey(funcName, vaultUrl, certificateName, strings.Join(opts, ";"))
+	ret, err := e.cacheResult(cacheKey, func() (interface{}, error) {
+		version := ""
+		if len(opts) == 1 {
+			version = opts[0]
+		}
+
+		certificate, err := e.providers.KeyVault.GetCertificate(e.ctx, vaultUrl, certificateName, version)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to fetch certificate "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, certificateName, err)
+		}
+
+		ret := models.NewAzCertificateItem(certificate)
+		if len(certificate.CER) > 0 {
+			leaf, err := x509.ParseCertificate(certificate.CER)
+			if err != nil {
+				return nil, fmt.Errorf(`unable to parse certificate "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, certificateName, err)
+			}
+
+			ret.Certificate = encodeCertificatesPem(leaf)
+			ret.Subject = leaf.Subject.String()
+			ret.Issuer = leaf.Issuer.String()
+			ret.SerialNumber = strings.ToUpper(leaf.SerialNumber.Text(16))
+			ret.DNSNames = leaf.DNSNames
+		}
+
+		if withPrivateKey {
+			if certificate.SID == nil {
+				return nil, fmt.Errorf(`unable to fetch private key of certificate "%[2]v" from vault "%[1]v": certificate has no backing secret`, vaultUrl, certificateName)
+			}
+
+			// certificate and private key are stored in the backing secret with the same name and version
+			secret, err := e.providers.KeyVault.GetSecret(e.ctx, vaultUrl, certificate.SID.Name(), certificate.SID.Version())
+			if err != nil {
+				return nil, fmt.Errorf(`unable to fetch private key of certificate "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, certificateName, err)
+			}
+
+			bundle, err := decodeCertificateBundle(to.String(secret.ContentType), to.String(secret.Value))
+			if err != nil {
+				return nil, fmt.Errorf(`unable to decode private key of certificate "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, certificateName, err)
+			}
+
+			if bundle.PrivateKey == nil {
+				return nil, fmt.Errorf(`unable to fetch private key of certificate "%[2]v" from vault "%[1]v": private key is not exportable`, vaultUrl, certificateName)
+			}
+
+			privateKey, err := bundle.PrivateKeyPem()
+			if err != nil {
+				return nil, fmt.Errorf(`unable to encode private key of certificate "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, certificateName, err)
+			}
+
+			e.handleCicdMaskSecretLines(privateKey)
+
+			ret.Chain = encodeCertificatesPem(bundle.Chain...)
+			ret.TlsCrt = encodeCertificatesPem(bundle.Certificates()...)
+			ret.TlsKey = privateKey
+		}
+
+		e.logger.Info(`using Azure KeyVault certificate`, slog.String("keyvault", vaultUrl), slog.String("certificate", certificateName), slog.String("version", ret.Version), slog.String("thumbprint", ret.Thumbprint))
+
+		return transformToInterface(ret)
+	})
+	if err != nil {
+		return ret, err
+	}
+
+	if e.prefetchOnly {
+		return ret, nil
+	}
+
+	// summary and attributes are checked for every call as results could be cached
+	certificate, err := parseKeyVaultCertificateItem(ret)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to use certificate "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, certificateName, err)
+	}
+
+	e.addSummaryKeyvaultCertificate(vaultUrl, certificate)
+
+	if certificate.Attributes != nil {
+		if err := e.checkKeyVaultItemAttributes("certificate", vaultUrl, certificateName, certificate.Attributes.Enabled, certificate.Attributes.NotBefore, certificate.Attributes.Expires); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// parseKeyVaultCertificateItem converts result of azKeyVaultCertificate back to certificate
+func parseKeyVaultCertificateItem(val interface{}) (*models.AzCertificate, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.AzCertificate{}
+	if err := json.Unmarshal(data, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// azKeyVaultCertificateList fetches certificates (without certificate data) from Azure KeyVault
+func (e *AzureTemplateExecutor) azKeyVaultCertificateList(vaultUrl string, certificateNamePattern string) (interface{}, error) {
+	// azure keyvault url detection
+	if val, err := e.buildAzKeyVaulUrl(vaultUrl); err == nil {
+		vaultUrl = val
+	} else {
+		return nil, err
+	}
+
+	e.logger.Info(`fetching Azure KeyVault certificate list from vault`, slog.String("keyvault", vaultUrl))
+
+	certificateNamePatternRegExp, err := regexp.Compile(certificateNamePattern)
+	if err != nil {
+		if e.LintMode {
+			return nil, e.lintFinding(LintRuleInvalidRegexp, fmt.Sprintf(`unable to compile Regular Expression "%v": %v`, certificateNamePattern, err))
+		}
+		return nil, fmt.Errorf(`unable to compile Regular Expression "%v": %w`, certificateNamePattern, err)
+	}
+
+	if val, enabled := e.lintResult(); enabled {
+		return val, nil
+	}
+	cacheKey := generateCacheKey(`azKeyVaultCertificateList`, vaultUrl)
+	list, err := e.cacheResult(cacheKey, func() (interface{}, error) {
+		result, err := e.providers.KeyVault.ListCertificates(e.ctx, vaultUrl)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to list certificates from vault "%v": %w`, vaultUrl, err)
+		}
+
+		ret := map[string]interface{}{}
+		for _, certificate := range result {
+			certificateData, err := transformToInterface(models.NewAzCertificateItemFromCertificateProperties(*certificate))
+			if err != nil {
+				return nil, fmt.Errorf(`unable to transform KeyVault certificate '%v': %w`, certificate.ID.Name(), err)
+			}
+			ret[certificate.ID.Name()] = certificateData
+		}
+
+		return transformToInterface(ret)
+	})
+	if err != nil {
+		return list, err
+	}
+
+	// filter list
+	if certificateList, ok := list.(map[string]interface{}); ok {
+		ret := map[string]interface{}{}
+		for certificateName, certificate := range certificateList {
+			if certificateNamePatternRegExp.MatchString(certificateName) {
+				ret[certificateName] = certificate
+			}
+		}
+		list = ret
+	}
+
+	return list, nil
+}
@@ -1,16 +1,17 @@
 package azuretpl
 
 import (
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
-	"github.com/webdevops/go-common/azuresdk/cloudconfig"
 	"github.com/webdevops/go-common/utils/to"
 
 	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
@@ -25,16 +26,13 @@ func (e *AzureTemplateExecutor) buildAzKeyVaulUrl(vaultUrl string) (string, erro
 
 	// vault url generation (if only vault name is specified)
 	if !strings.HasPrefix(strings.ToLower(vaultUrl), "https://") {
-		switch cloudName := e.azureClient().GetCloudName(); cloudName {
-		case cloudconfig.AzurePublicCloud:
-			vaultUrl = fmt.Sprintf(`https://%s.vault.azure.net`, vaultUrl)
-		case cloudconfig.AzureChinaCloud:
-			vaultUrl = fmt.Sprintf(`https://%s.vault.azure.cn`, vaultUrl)
-		case cloudconfig.AzureGovernmentCloud:
-			vaultUrl = fmt.Sprintf(`https://%s.vault.usgovcloudapi.net`, vaultUrl)
-		default:
-			return vaultUrl, fmt.Errorf(`cannot build Azure KeyVault url for "%s" and Azure cloud "%s", please use full url`, vaultUrl, cloudName)
+		url, err := e.buildCloudHostUrl(vaultUrl, func(endpoints CloudEndpoints) string {
+			return endpoints.KeyVaultDNSSuffix
+		}, "keyVaultDNSSuffix")
+		if err != nil {
+			return vaultUrl, err
 		}
+		vaultUrl = url
 	}
 
 	// improve caching by removing trailing slash
@@ -43,6 +41,58 @@ func (e *AzureTemplateExecutor) buildAzKeyVaulUrl(vaultUrl string) (string, erro
 	return vaultUrl, nil
 }
 
+// checkKeyVaultItemAttributes checks if KeyVault item (secret, certificate or key) is enabled, active and not expired,
+// items expiring soon (see --keyvault.expiry.warningduration) are reported as warning
+func (e *AzureTemplateExecutor) checkKeyVaultItemAttributes(itemType, vaultUrl, itemName string, enabled *bool, notBefore, expires *time.Time) error {
+	if enabled != nil && !*enabled {
+		return fmt.Errorf(`unable to use Azure KeyVault %[1]v '%[2]v' -> '%[3]v': %[1]v is disabled`, itemType, vaultUrl, itemName)
+	}
+
+	if notBefore != nil && time.Now().Before(*notBefore) {
+		return fmt.Errorf(`unable to use Azure KeyVault %[1]v '%[2]v' -> '%[3]v': %[1]v is not yet active (notBefore: %[4]v)`, itemType, vaultUrl, itemName, notBefore.Format(time.RFC3339))
+	}
+
+	if expires != nil {
+		// item has expiry date, let's check it
+
+		if time.Now().After(*expires) {
+			// item is expired
+			if !e.opts.Keyvault.IgnoreExpiry {
+				return fmt.Errorf(`unable to use Azure KeyVault %[1]v '%[2]v' -> '%[3]v': %[1]v is expired (expires: %[4]v, set env AZURETPL_KEYVAULT_EXPIRY_IGNORE=1 to ignore)`, itemType, vaultUrl, itemName, expires.Format(time.RFC3339))
+			} else if e.isFirstKeyVaultItemWarning(itemType, vaultUrl, itemName, expires) {
+				e.logger.Warn(
+					e.handleCicdWarning(
+						fmt.Errorf(`found expiring Azure KeyVault %[1]v '%[2]v' -> '%[3]v': %[1]v is expired, but env AZURETPL_KEYVAULT_EXPIRY_IGNORE=1 is active (expires: %[4]v)`, itemType, vaultUrl, itemName, expires.Format(time.RFC3339)),
+					),
+				)
+			}
+		} else if time.Now().Add(e.opts.Keyvault.ExpiryWarning).After(*expires) && e.isFirstKeyVaultItemWarning(itemType, vaultUrl, itemName, expires) {
+			// item is expiring soon
+			e.logger.Warn(
+				e.handleCicdWarning(
+					fmt.Errorf(`found expiring Azure KeyVault %[1]v '%[2]v' -> '%[3]v': %[1]v is expiring soon (expires: %[4]v)`, itemType, vaultUrl, itemName, expires.Format(time.RFC3339)),
+				),
+			)
+
+			e.addSummaryLine(
+				"warnings",
+				fmt.Sprintf(
+					` - found expiring Azure KeyVault %[1]v '%[2]v' -> '%[3]v': %[1]v is expiring soon (expires: %[4]v)`,
+					itemType, vaultUrl, itemName, expires.Format(time.RFC3339),
+				),
+			)
+		}
+	}
+
+	return nil
+}
+
+// isFirstKeyVaultItemWarning returns true if warning for KeyVault item wasn't reported yet (items are checked on every call)
+func (e *AzureTemplateExecutor) isFirstKeyVaultItemWarning(itemType, vaultUrl, itemName string, expires *time.Time) bool {
+	warningKey := strings.Join([]string{itemType, vaultUrl, itemName, expires.Format(time.RFC3339)}, "|")
+	return e.summary.firstReport(warningKey)
+}
+
 // azKeyVaultSecret fetches secret object from Azure KeyVault
 func (e *AzureTemplateExecutor) azKeyVaultSecret(vaultUrl string, secretName string, opts ...string) (interface{}, error) {
 	// azure keyvault url detection
@@ -57,70 +107,84 @@ func (e *AzureTemplateExecutor) azKeyVaultSecret(vaultUrl string, secretName str
 	if val, enabled := e.lintResult(); enabled {
 		return val, nil
 	}
-	cacheKey := generateCacheKey(`azKeyVaultSecret`, vaultUrl, secretName, strings.Join(opts, ";"))
-	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		secretClient, err := azsecrets.NewClient(vaultUrl, e.azureClient().GetCred(), nil)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to create keyvault client for vault "%v": %w`, vaultUrl, err)
-		}
 
-		version := ""
-		if len(opts) == 1 {
-			version = opts[0]
+	ret, err := e.fetchKeyVaultSecret(vaultUrl, secretName, opts...)
+	if err != nil {
+		return ret, err
+	}
+
+	if e.prefetchOnly {
+		return ret, nil
+	}
+
+	// policy is checked for every call as results could be cached
+	if err := e.checkKeyVaultSecretPolicy(vaultUrl, ret); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// fetchKeyVaultSecret fetches secret object from Azure KeyVault (vaultUrl must be a full url),
+// uses the pinned version in locked mode and records the used version if no version is specified
+func (e *AzureTemplateExecutor) fetchKeyVaultSecret(vaultUrl string, secretName string, opts ...string) (interface{}, error) {
+	version := ""
+	if len(opts) == 1 {
+		version = opts[0]
+	}
+
+	// use pinned version of lock file if no version is specified
+	useLock := version == ""
+	if useLock && e.lockFile.isLocked() {
+		pinnedVersion, err := e.lockedKeyVaultSecretVersion(vaultUrl, secretName)
+		if err != nil {
+			return nil, err
 		}
+		version = pinnedVersion
+		opts = []string{version}
+	}
 
-		secret, err := secretClient.GetSecret(e.ctx, secretName, version, nil)
+	cacheKey := generateCacheKey(`azKeyVaultSecret`, vaultUrl, secretName, strings.Join(opts, ";"))
+	ret, err := e.cacheResult(cacheKey, func() (interface{}, error) {
+		secret, err := e.providers.KeyVault.GetSecret(e.ctx, vaultUrl, secretName, version)
 		if err != nil {
 			return nil, fmt.Errorf(`unable to fetch secret "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, secretName, err)
 		}
 
-		e.addSummaryKeyvaultSecret(vaultUrl, secret)
+		e.logger.Info(`using Azure KeyVault secret`, slog.String("keyvault", vaultUrl), slog.String("secret", secretName), slog.String("version", secret.ID.Version()))
+		e.handleCicdMaskSecret(to.String(secret.Value))
 
-		if !*secret.Attributes.Enabled {
-			return nil, fmt.Errorf(`unable to use Azure KeyVault secret '%v' -> '%v': secret is disabled`, vaultUrl, secretName)
-		}
+		return transformToInterface(models.NewAzSecretItem(secret))
+	})
+	if err != nil {
+		return ret, err
+	}
 
-		if secret.Attributes.NotBefore != nil && time.Now().Before(*secret.Attributes.NotBefore) {
-			return nil, fmt.Errorf(`unable to use Azure KeyVault secret '%v' -> '%v': secret is not yet active (notBefore: %v)`, vaultUrl, secretName, secret.Attributes.NotBefore.Format(time.RFC3339))
-		}
+	if e.prefetchOnly {
+		return ret, nil
+	}
 
-		if secret.Attributes.Expires != nil {
-			// secret has expiry date, let's check it
-
-			if time.Now().After(*secret.Attributes.Expires) {
-				// secret is expired
-				if !e.opts.Keyvault.IgnoreExpiry {
-					return nil, fmt.Errorf(`unable to use Azure KeyVault secret '%v' -> '%v': secret is expired (expires: %v, set env AZURETPL_KEYVAULT_EXPIRY_IGNORE=1 to ignore)`, vaultUrl, secretName, secret.Attributes.Expires.Format(time.RFC3339))
-				} else {
-					e.logger.Warn(
-						e.handleCicdWarning(
-							fmt.Errorf(`found expiring Azure KeyVault secret '%v' -> '%v': secret is expired, but env AZURETPL_KEYVAULT_EXPIRY_IGNORE=1 is active (expires: %v)`, vaultUrl, secretName, secret.Attributes.Expires.Format(time.RFC3339)),
-						),
-					)
-				}
-			} else if time.Now().Add(e.opts.Keyvault.ExpiryWarning).After(*secret.Attributes.Expires) {
-				// secret is expiring soon
-				e.logger.Warn(
-					e.handleCicdWarning(
-						fmt.Errorf(`found expiring Azure KeyVault secret '%v' -> '%v': secret is expiring soon (expires: %v)`, vaultUrl, secretName, secret.Attributes.Expires.Format(time.RFC3339)),
-					),
-				)
+	// summary and attributes are checked for every call as results could be cached
+	secret, err := parseKeyVaultSecretListItem(ret)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to use secret "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, secretName, err)
+	}
 
-				e.addSummaryLine(
-					"warnings",
-					fmt.Sprintf(
-						` - found expiring Azure KeyVault secret '%v' -> '%v': secret is expiring soon (expires: %v)`,
-						vaultUrl, secretName, secret.Attributes.Expires.Format(time.RFC3339),
-					),
-				)
-			}
+	e.addSummaryKeyvaultSecret(vaultUrl, secret)
+
+	if secret.Attributes != nil {
+		if err := e.checkKeyVaultItemAttributes("secret", vaultUrl, secretName, secret.Attributes.Enabled, secret.Attributes.NotBefore, secret.Attributes.Expires); err != nil {
+			return nil, err
 		}
+	}
 
-		e.logger.Info(`using Azure KeyVault secret`, slog.String("keyvault", vaultUrl), slog.String("secret", secretName), slog.String("version", secret.ID.Version()))
-		e.handleCicdMaskSecret(to.String(secret.Value))
+	if secret, ok := ret.(map[string]interface{}); ok && useLock {
+		if resolvedVersion, ok := secret["version"].(string); ok {
+			e.lockFile.resolveEntry(e.lockTemplateName(), LockEntryKeyVaultSecret, keyVaultSecretLockId(vaultUrl, secretName), resolvedVersion)
+		}
+	}
 
-		return transformToInterface(models.NewAzSecretItem(secret.Secret))
-	})
+	return ret, nil
 }
 
 // azKeyVaultSecretVersions fetches older versions of one secret from Azure KeyVault
@@ -137,44 +201,30 @@ func (e *AzureTemplateExecutor) azKeyVaultSecretVersions(vaultUrl string, secret
 	if val, enabled := e.lintResult(); enabled {
 		return val, nil
 	}
-	cacheKey := generateCacheKey(`azKeyVaultSecretHistory`, vaultUrl, secretName, strconv.Itoa(count))
-	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		secretClient, err := azsecrets.NewClient(vaultUrl, e.azureClient().GetCred(), nil)
+	cacheKey := generateCacheKey(`azKeyVaultSecretVersions`, vaultUrl, secretName, strconv.Itoa(count))
+	ret, err := e.cacheResult(cacheKey, func() (interface{}, error) {
+		// WARNING: secrets are ordered by version instead of creation date
+		// so we cannot limit paging to just a few pages as even the current secrets
+		// could be on the next or last page.
+		// this was an awful design decision from Azure not to order the entries by creation date.
+		// so we have to get the full list of versions for filtering,
+		// otherwise we might miss important entries.
+		// luckily versions are limited to just 500 entries but it's still an awful trap.
+		// the same applies to the Azure KeyVault secret listing in the Azure Portal,
+		// it's a mess, horrible to debug and a trap for all developers.
+		result, err := e.providers.KeyVault.ListSecretVersions(e.ctx, vaultUrl, secretName)
 		if err != nil {
-			return nil, fmt.Errorf(`failed to create keyvault client for vault "%v": %w`, vaultUrl, err)
+			return nil, fmt.Errorf(`unable to list versions of secret "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, secretName, err)
 		}
 
-		pager := secretClient.NewListSecretPropertiesVersionsPager(secretName, nil)
-
 		// get secrets first
 		secretList := []*azsecrets.SecretProperties{}
-		for pager.More() {
-			result, err := pager.NextPage(e.ctx)
-			if err != nil {
-				panic(err)
+		for _, secretVersion := range result {
+			if secretVersion.Attributes == nil || secretVersion.Attributes.Enabled == nil || !*secretVersion.Attributes.Enabled {
+				continue
 			}
 
-			for _, secretVersion := range result.Value {
-				if !*secretVersion.Attributes.Enabled {
-					continue
-				}
-
-				secretList = append(secretList, secretVersion)
-			}
-
-			// WARNING: secrets are ordered by version instead of creation date
-			// so we cannot limit paging to just a few pages as even the current secrets
-			// could be on the next or last page.
-			// this was an awful design decision from Azure not to order the entries by creation date.
-			// so we have to get the full list of versions for filtering,
-			// otherwise we might miss important entries.
-			// luckily versions are limited to just 500 entries but it's still an awful trap.
-			// the same applies to the Azure KeyVault secret listing in the Azure Portal,
-			// it's a mess, horrible to debug and a trap for all developers.
-			//
-			// if count >= 0 && len(secretList) >= count {
-			// 	break
-			// }
+			secretList = append(secretList, secretVersion)
 		}
 
 		// sort results
@@ -185,16 +235,14 @@ func (e *AzureTemplateExecutor) azKeyVaultSecretVersions(vaultUrl string, secret
 		// process list
 		ret := []interface{}{}
 		for _, secretVersion := range secretList {
-			secret, err := secretClient.GetSecret(e.ctx, secretVersion.ID.Name(), secretVersion.ID.Version(), nil)
+			secret, err := e.providers.KeyVault.GetSecret(e.ctx, vaultUrl, secretVersion.ID.Name(), secretVersion.ID.Version())
 			if err != nil {
 				return nil, fmt.Errorf(`unable to fetch secret "%[2]v" with version "%[3]v" from vault "%[1]v": %[4]w`, vaultUrl, secretVersion.ID.Name(), secretVersion.ID.Version(), err)
 			}
 
-			e.addSummaryKeyvaultSecret(vaultUrl, secret)
-
 			e.handleCicdMaskSecret(to.String(secret.Value))
 
-			if val, err := transformToInterface(models.NewAzSecretItem(secret.Secret)); err == nil {
+			if val, err := transformToInterface(models.NewAzSecretItem(secret)); err == nil {
 				ret = append(ret, val)
 			} else {
 				return nil, err
@@ -207,6 +255,27 @@ func (e *AzureTemplateExecutor) azKeyVaultSecretVersions(vaultUrl string, secret
 
 		return ret, nil
 	})
+	if err != nil {
+		return ret, err
+	}
+
+	if e.prefetchOnly {
+		return ret, nil
+	}
+
+	// summary is added for every call as results could be cached
+	if secretList, ok := ret.([]interface{}); ok {
+		for _, secretData := range secretList {
+			secret, err := parseKeyVaultSecretListItem(secretData)
+			if err != nil {
+				return nil, fmt.Errorf(`unable to use versions of secret "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, secretName, err)
+			}
+
+			e.addSummaryKeyvaultSecret(vaultUrl, secret)
+		}
+	}
+
+	return ret, nil
 }
 
 // azKeyVaultSecretList fetches secrets from Azure KeyVault
@@ -222,6 +291,9 @@ func (e *AzureTemplateExecutor) azKeyVaultSecretList(vaultUrl string, secretName
 
 	secretNamePatternRegExp, err := regexp.Compile(secretNamePattern)
 	if err != nil {
+		if e.LintMode {
+			return nil, e.lintFinding(LintRuleInvalidRegexp, fmt.Sprintf(`unable to compile Regular Expression "%v": %v`, secretNamePattern, err))
+		}
 		return nil, fmt.Errorf(`unable to compile Regular Expression "%v": %w`, secretNamePattern, err)
 	}
 
@@ -230,27 +302,18 @@ func (e *AzureTemplateExecutor) azKeyVaultSecretList(vaultUrl string, secretName
 	}
 	cacheKey := generateCacheKey(`azKeyVaultSecretList`, vaultUrl)
 	list, err := e.cacheResult(cacheKey, func() (interface{}, error) {
-		secretClient, err := azsecrets.NewClient(vaultUrl, e.azureClient().GetCred(), nil)
+		result, err := e.providers.KeyVault.ListSecrets(e.ctx, vaultUrl)
 		if err != nil {
-			return nil, fmt.Errorf(`failed to create keyvault client for vault "%v": %w`, vaultUrl, err)
+			return nil, fmt.Errorf(`unable to list secrets from vault "%v": %w`, vaultUrl, err)
 		}
 
-		pager := secretClient.NewListSecretPropertiesPager(nil)
-
 		ret := map[string]interface{}{}
-		for pager.More() {
-			result, err := pager.NextPage(e.ctx)
+		for _, secret := range result {
+			secretData, err := transformToInterface(models.NewAzSecretItemFromSecretproperties(*secret))
 			if err != nil {
-				panic(err)
-			}
-
-			for _, secret := range result.Value {
-				secretData, err := transformToInterface(models.NewAzSecretItemFromSecretproperties(*secret))
-				if err != nil {
-					return nil, fmt.Errorf(`unable to transform KeyVault secret '%v': %w`, secret.ID.Name(), err)
-				}
-				ret[secret.ID.Name()] = secretData
+				return nil, fmt.Errorf(`unable to transform KeyVault secret '%v': %w`, secret.ID.Name(), err)
 			}
+			ret[secret.ID.Name()] = secretData
 		}
 
 		return transformToInterface(ret)
@@ -272,3 +335,196 @@ func (e *AzureTemplateExecutor) azKeyVaultSecretList(vaultUrl string, secretName
 
 	return list, nil
 }
+
+type (
+	// keyVaultSecretValuesFilter filters secrets of azKeyVaultSecretValues (besides the name pattern)
+	keyVaultSecretValuesFilter struct {
+		// Tags must all be set on the secret, value "*" matches any tag value
+		Tags map[string]string
+
+		// ContentType must match the content type of the secret (case-insensitive, only used if set)
+		ContentType *string
+
+		// IncludeExpired also fetches expired secrets (fails unless --keyvault.expiry.ignore is set)
+		IncludeExpired bool
+
+		// ValidFor skips secrets expiring within this duration
+		ValidFor time.Duration
+	}
+)
+
+// azKeyVaultSecretValues fetches values of all secrets matching secretNamePattern and filter concurrently from Azure KeyVault,
+// returns map of secret name and value
+func (e *AzureTemplateExecutor) azKeyVaultSecretValues(vaultUrl string, secretNamePattern string, filter ...map[string]interface{}) (interface{}, error) {
+	secretFilter, err := parseKeyVaultSecretValuesFilter(filter...)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := e.azKeyVaultSecretList(vaultUrl, secretNamePattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if val, enabled := e.lintResult(); enabled {
+		return val, nil
+	}
+
+	secretList, ok := list.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf(`unable to use secret list of vault "%v": unexpected type %T`, vaultUrl, list)
+	}
+
+	secretNames := []string{}
+	for secretName, secretData := range secretList {
+		secret, err := parseKeyVaultSecretListItem(secretData)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to use secret "%[2]v" of vault "%[1]v": %[3]w`, vaultUrl, secretName, err)
+		}
+
+		if reason := secretFilter.skipReason(secret); reason != "" {
+			e.logger.Debug(`skipping Azure KeyVault secret`, slog.String("keyvault", vaultUrl), slog.String("secret", secretName), slog.String("reason", reason))
+			continue
+		}
+
+		secretNames = append(secretNames, secretName)
+	}
+	sort.Strings(secretNames)
+
+	e.logger.Info(`fetching Azure KeyVault secret values`, slog.String("keyvault", vaultUrl), slog.Int("secrets", len(secretNames)))
+
+	concurrency := e.opts.Keyvault.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	ret := map[string]interface{}{}
+	errList := make([]error, len(secretNames))
+	lock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	semaphore := make(chan struct{}, concurrency)
+	for i, secretName := range secretNames {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			secret, err := e.azKeyVaultSecret(vaultUrl, secretName)
+			if err != nil {
+				errList[i] = err
+				return
+			}
+
+			if secretData, ok := secret.(map[string]interface{}); ok {
+				lock.Lock()
+				ret[secretName] = secretData["value"]
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// report first error (by secret name) to be deterministic
+	for _, err := range errList {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// parseKeyVaultSecretValuesFilter parses the filter of azKeyVaultSecretValues (eg. `dict "tags" (dict "env" "prod") "contentType" "text/plain"`)
+func parseKeyVaultSecretValuesFilter(filter ...map[string]interface{}) (keyVaultSecretValuesFilter, error) {
+	ret := keyVaultSecretValuesFilter{}
+	if len(filter) > 1 {
+		return ret, fmt.Errorf(`only one filter is allowed`)
+	}
+
+	for _, options := range filter {
+		for key, val := range options {
+			switch key {
+			case "tags":
+				tags, ok := val.(map[string]interface{})
+				if !ok {
+					return ret, fmt.Errorf(`filter "tags" must be a dict, got %T`, val)
+				}
+
+				ret.Tags = map[string]string{}
+				for tagName, tagValue := range tags {
+					ret.Tags[tagName] = fmt.Sprintf("%v", tagValue)
+				}
+			case "contentType":
+				contentType := fmt.Sprintf("%v", val)
+				ret.ContentType = &contentType
+			case "includeExpired":
+				includeExpired, ok := val.(bool)
+				if !ok {
+					return ret, fmt.Errorf(`filter "includeExpired" must be a boolean, got %T`, val)
+				}
+				ret.IncludeExpired = includeExpired
+			case "validFor":
+				validFor, err := time.ParseDuration(fmt.Sprintf("%v", val))
+				if err != nil {
+					return ret, fmt.Errorf(`filter "validFor" must be a duration (eg. 24h): %w`, err)
+				}
+				ret.ValidFor = validFor
+			default:
+				return ret, fmt.Errorf(`unknown filter "%v", supported filters are tags, contentType, includeExpired and validFor`, key)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// parseKeyVaultSecretListItem converts item of azKeyVaultSecretList back to secret
+func parseKeyVaultSecretListItem(val interface{}) (*models.AzSecret, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.AzSecret{}
+	if err := json.Unmarshal(data, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// skipReason returns why the secret doesn't match the filter (empty if secret matches)
+func (f keyVaultSecretValuesFilter) skipReason(secret *models.AzSecret) string {
+	if attributes := secret.Attributes; attributes != nil {
+		if attributes.Enabled != nil && !*attributes.Enabled {
+			return "secret is disabled"
+		}
+
+		if attributes.NotBefore != nil && time.Now().Before(*attributes.NotBefore) {
+			return "secret is not yet active"
+		}
+
+		if attributes.Expires != nil {
+			if !f.IncludeExpired && time.Now().After(*attributes.Expires) {
+				return "secret is expired"
+			}
+
+			if f.ValidFor > 0 && time.Now().Add(f.ValidFor).After(*attributes.Expires) {
+				return fmt.Sprintf("secret is expiring within %v", f.ValidFor)
+			}
+		}
+	}
+
+	if f.ContentType != nil && !strings.EqualFold(to.String(secret.ContentType), *f.ContentType) {
+		return "content type doesn't match"
+	}
+
+	for tagName, tagValue := range f.Tags {
+		secretTagValue, exists := secret.Tags[tagName]
+		if !exists || (tagValue != "*" && to.String(secretTagValue) != tagValue) {
+			return fmt.Sprintf("tag %v doesn't match", tagName)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,141 @@
+package azuretpl
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/webdevops/go-common/utils/to"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
+)
+
+// azKeyVaultKey fetches public key (as JWK and PEM) from Azure KeyVault
+func (e *AzureTemplateExecutor) azKeyVaultKey(vaultUrl string, keyName string, opts ...string) (interface{}, error) {
+	// azure keyvault url detection
+	if val, err := e.buildAzKeyVaulUrl(vaultUrl); err == nil {
+		vaultUrl = val
+	} else {
+		return nil, err
+	}
+
+	e.logger.Info(`fetching Azure KeyVault key`, slog.String("keyvault", vaultUrl), slog.String("key", keyName))
+
+	if val, enabled := e.lintResult(); enabled {
+		return val, nil
+	}
+	cacheKey := generateCacheKey(`azKeyVaultKey`, vaultUrl, keyName, strings.Join(opts, ";"))
+	ret, err := e.cacheResult(cacheKey, func() (interface{}, error) {
+		version := ""
+		if len(opts) == 1 {
+			version = opts[0]
+		}
+
+		key, err := e.providers.KeyVault.GetKey(e.ctx, vaultUrl, keyName, version)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to fetch key "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, keyName, err)
+		}
+
+		if key.Key == nil || key.Key.KID == nil {
+			return nil, fmt.Errorf(`unable to fetch key "%[2]v" from vault "%[1]v": key material is missing`, vaultUrl, keyName)
+		}
+
+		ret := models.NewAzKeyItem(key)
+
+		publicKey, err := jsonWebKeyPublicKey(key.Key)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to convert key "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, keyName, err)
+		}
+
+		if publicKey != nil {
+			if ret.PublicKey, err = encodePublicKeyPem(publicKey); err != nil {
+				return nil, fmt.Errorf(`unable to convert key "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, keyName, err)
+			}
+		}
+
+		e.logger.Info(`using Azure KeyVault key`, slog.String("keyvault", vaultUrl), slog.String("key", keyName), slog.String("version", ret.Version))
+
+		return transformToInterface(ret)
+	})
+	if err != nil {
+		return ret, err
+	}
+
+	if e.prefetchOnly {
+		return ret, nil
+	}
+
+	// summary and attributes are checked for every call as results could be cached
+	key, err := parseKeyVaultKeyItem(ret)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to use key "%[2]v" from vault "%[1]v": %[3]w`, vaultUrl, keyName, err)
+	}
+
+	e.addSummaryKeyvaultKey(vaultUrl, key)
+
+	if key.Attributes != nil {
+		if err := e.checkKeyVaultItemAttributes("key", vaultUrl, keyName, key.Attributes.Enabled, key.Attributes.NotBefore, key.Attributes.Expires); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// parseKeyVaultKeyItem converts result of azKeyVaultKey back to key
+func parseKeyVaultKeyItem(val interface{}) (*models.AzKey, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.AzKey{}
+	if err := json.Unmarshal(data, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// jsonWebKeyPublicKey returns the public key of JSON web key (nil for symmetric keys or unsupported curves)
+func jsonWebKeyPublicKey(key *azkeys.JSONWebKey) (crypto.PublicKey, error) {
+	switch azkeys.KeyType(to.String((*string)(key.Kty))) {
+	case azkeys.KeyTypeRSA, azkeys.KeyTypeRSAHSM:
+		if len(key.N) == 0 || len(key.E) == 0 {
+			return nil, errors.New(`RSA key is missing modulus or exponent`)
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(key.N),
+			E: int(new(big.Int).SetBytes(key.E).Int64()),
+		}, nil
+	case azkeys.KeyTypeEC, azkeys.KeyTypeECHSM:
+		var curve elliptic.Curve
+		switch azkeys.CurveName(to.String((*string)(key.Crv))) {
+		case azkeys.CurveNameP256:
+			curve = elliptic.P256()
+		case azkeys.CurveNameP384:
+			curve = elliptic.P384()
+		case azkeys.CurveNameP521:
+			curve = elliptic.P521()
+		default:
+			// eg. P-256K is not supported by PKIX encoding
+			return nil, nil
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(key.X),
+			Y:     new(big.Int).SetBytes(key.Y),
+		}, nil
+	}
+
+	return nil, nil
+}
@@ -5,7 +5,6 @@ import (
 	"log/slog"
 	"strings"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/managementgroups/armmanagementgroups"
 	"github.com/webdevops/go-common/utils/to"
 )
 
@@ -19,12 +18,7 @@ func (e *AzureTemplateExecutor) azManagementGroup(groupID string) (interface{},
 
 	cacheKey := generateCacheKey(`azManagementGroup`, groupID)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		client, err := armmanagementgroups.NewClient(e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
-		if err != nil {
-			return nil, fmt.Errorf(`failed to create ManagementGroup "%v": %w`, groupID, err)
-		}
-
-		managementGroup, err := client.Get(e.ctx, groupID, nil)
+		managementGroup, err := e.providers.Resource.GetManagementGroup(e.ctx, groupID)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to fetch ManagementGroup "%v": %w`, groupID, err)
 		}
@@ -43,23 +37,15 @@ func (e *AzureTemplateExecutor) azManagementGroupSubscriptionList(groupID string
 
 	cacheKey := generateCacheKey(`azManagementGroupSubscriptionList`, groupID)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		client, err := armmanagementgroups.NewClient(e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
+		result, err := e.providers.Resource.ListManagementGroupDescendants(e.ctx, groupID)
 		if err != nil {
-			return nil, fmt.Errorf(`failed to create ManagementGroup "%v": %w`, groupID, err)
+			return nil, fmt.Errorf(`failed to fetch ManagementGroup descendants "%v": %w`, groupID, err)
 		}
 
-		pager := client.NewGetDescendantsPager(groupID, nil)
 		ret := []interface{}{}
-		for pager.More() {
-			result, err := pager.NextPage(e.ctx)
-			if err != nil {
-				panic(err)
-			}
-
-			for _, resource := range result.Value {
-				if strings.EqualFold(to.String(resource.Type), "Microsoft.Management/managementGroups/subscriptions") {
-					ret = append(ret, resource)
-				}
+		for _, resource := range result {
+			if strings.EqualFold(to.String(resource.Type), "Microsoft.Management/managementGroups/subscriptions") {
+				ret = append(ret, resource)
 			}
 		}
 		return transformToInterface(ret)
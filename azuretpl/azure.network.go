@@ -5,8 +5,6 @@ import (
 	"log/slog"
 	"strings"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
-	"github.com/webdevops/go-common/azuresdk/armclient"
 	"github.com/webdevops/go-common/utils/to"
 )
 
@@ -20,17 +18,7 @@ func (e *AzureTemplateExecutor) azPublicIpAddress(resourceID string) (interface{
 
 	cacheKey := generateCacheKey(`azPublicIpAddress`, resourceID)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		resourceInfo, err := armclient.ParseResourceId(resourceID)
-		if err != nil {
-			return nil, fmt.Errorf(`unable to parse Azure resourceID '%v': %w`, resourceID, err)
-		}
-
-		client, err := armnetwork.NewPublicIPAddressesClient(resourceInfo.Subscription, e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
-		if err != nil {
-			return nil, err
-		}
-
-		pipAddress, err := client.Get(e.ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+		pipAddress, err := e.providers.Resource.GetPublicIPAddress(e.ctx, resourceID)
 		if err != nil {
 			return nil, fmt.Errorf(`unable to fetch Azure resource '%v': %w`, resourceID, err)
 		}
@@ -49,17 +37,7 @@ func (e *AzureTemplateExecutor) azPublicIpPrefixAddressPrefix(resourceID string)
 
 	cacheKey := generateCacheKey(`azPublicIpPrefixAddressPrefix`, resourceID)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		resourceInfo, err := armclient.ParseResourceId(resourceID)
-		if err != nil {
-			return nil, fmt.Errorf(`unable to parse Azure resourceID '%v': %w`, resourceID, err)
-		}
-
-		client, err := armnetwork.NewPublicIPPrefixesClient(resourceInfo.Subscription, e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
-		if err != nil {
-			return nil, err
-		}
-
-		pipAddress, err := client.Get(e.ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+		pipAddress, err := e.providers.Resource.GetPublicIPPrefix(e.ctx, resourceID)
 		if err != nil {
 			return nil, fmt.Errorf(`unable to fetch Azure resource '%v': %w`, resourceID, err)
 		}
@@ -78,17 +56,7 @@ func (e *AzureTemplateExecutor) azVirtualNetworkAddressPrefixes(resourceID strin
 
 	cacheKey := generateCacheKey(`azVirtualNetworkAddressPrefixes`, resourceID)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		resourceInfo, err := armclient.ParseResourceId(resourceID)
-		if err != nil {
-			return nil, fmt.Errorf(`unable to parse Azure resourceID '%v': %w`, resourceID, err)
-		}
-
-		client, err := armnetwork.NewVirtualNetworksClient(resourceInfo.Subscription, e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
-		if err != nil {
-			return nil, err
-		}
-
-		vnet, err := client.Get(e.ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+		vnet, err := e.providers.Resource.GetVirtualNetwork(e.ctx, resourceID)
 		if err != nil {
 			return nil, fmt.Errorf(`unable to fetch Azure resource '%v': %w`, resourceID, err)
 		}
@@ -110,17 +78,7 @@ func (e *AzureTemplateExecutor) azVirtualNetworkSubnetAddressPrefixes(resourceID
 
 	cacheKey := generateCacheKey(`azVirtualNetworkSubnetAddressPrefixes`, resourceID, subnetName)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		resourceInfo, err := armclient.ParseResourceId(resourceID)
-		if err != nil {
-			return nil, fmt.Errorf(`unable to parse Azure resourceID '%v': %w`, resourceID, err)
-		}
-
-		client, err := armnetwork.NewVirtualNetworksClient(resourceInfo.Subscription, e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
-		if err != nil {
-			return nil, err
-		}
-
-		vnet, err := client.Get(e.ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+		vnet, err := e.providers.Resource.GetVirtualNetwork(e.ctx, resourceID)
 		if err != nil {
 			return nil, fmt.Errorf(`unable to fetch Azure resource '%v': %w`, resourceID, err)
 		}
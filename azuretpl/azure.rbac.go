@@ -3,9 +3,6 @@ package azuretpl
 import (
 	"fmt"
 	"log/slog"
-
-	armauthorization "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
-	"github.com/webdevops/go-common/utils/to"
 )
 
 // azRoleDefinition fetches Azure RoleDefinition by roleName
@@ -23,7 +20,7 @@ func (e *AzureTemplateExecutor) azRoleDefinition(scope string, roleName string)
 			roleName,
 		)
 
-		result, err := e.fetchAzureRoleDefinitions(scope, filter)
+		result, err := e.providers.Resource.ListRoleDefinitions(e.ctx, scope, filter)
 		if err != nil {
 			return nil, err
 		}
@@ -56,36 +53,10 @@ func (e *AzureTemplateExecutor) azRoleDefinitionList(scope string, filter ...str
 
 	cacheKey := generateCacheKey(`azRoleDefinitionList`, scope, roleDefinitionFilter)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		result, err := e.fetchAzureRoleDefinitions(scope, roleDefinitionFilter)
+		result, err := e.providers.Resource.ListRoleDefinitions(e.ctx, scope, roleDefinitionFilter)
 		if err != nil {
 			return nil, err
 		}
 		return transformToInterface(result)
 	})
 }
-
-func (e *AzureTemplateExecutor) fetchAzureRoleDefinitions(scope string, filter string) ([]armauthorization.RoleDefinition, error) {
-	client, err := armauthorization.NewRoleDefinitionsClient(e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
-	if err != nil {
-		return nil, err
-	}
-
-	listOpts := armauthorization.RoleDefinitionsClientListOptions{
-		Filter: to.StringPtr(filter),
-	}
-	pager := client.NewListPager(scope, &listOpts)
-
-	list := []armauthorization.RoleDefinition{}
-	for pager.More() {
-		result, err := pager.NextPage(e.ctx)
-		if err != nil {
-			return nil, err
-		}
-
-		for _, roleDefinition := range result.Value {
-			list = append(list, *roleDefinition)
-		}
-	}
-
-	return list, nil
-}
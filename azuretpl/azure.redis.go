@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"log/slog"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/redis/armredis"
-	"github.com/webdevops/go-common/azuresdk/armclient"
 	"github.com/webdevops/go-common/utils/to"
 )
 
@@ -19,19 +17,9 @@ func (e *AzureTemplateExecutor) azRedisAccessKeys(resourceID string) (interface{
 
 	cacheKey := generateCacheKey(`azRedisAccessKeys`, resourceID)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		resourceInfo, err := armclient.ParseResourceId(resourceID)
+		result, err := e.providers.Resource.ListRedisAccessKeys(e.ctx, resourceID)
 		if err != nil {
-			return nil, fmt.Errorf(`unable to parse Azure resourceID '%v': %w`, resourceID, err)
-		}
-
-		client, err := armredis.NewClient(resourceInfo.Subscription, e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
-		if err != nil {
-			return nil, err
-		}
-
-		result, err := client.ListKeys(e.ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
-		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf(`unable to fetch Azure Redis accesskeys '%v': %w`, resourceID, err)
 		}
 
 		val := []string{
@@ -24,7 +24,7 @@ func (e *AzureTemplateExecutor) azResourceGraphQuery(scope interface{}, query st
 
 			err := parseResourceGraphScope(val, &resourceGraphOptions)
 			if err != nil {
-				panic(err)
+				return nil, err
 			}
 		}
 	case []string:
@@ -33,7 +33,7 @@ func (e *AzureTemplateExecutor) azResourceGraphQuery(scope interface{}, query st
 		for _, val := range v {
 			err := parseResourceGraphScope(val, &resourceGraphOptions)
 			if err != nil {
-				panic(err)
+				return nil, err
 			}
 		}
 	default:
@@ -52,7 +52,7 @@ func (e *AzureTemplateExecutor) azResourceGraphQuery(scope interface{}, query st
 
 	cacheKey := generateCacheKey(`azResourceGraphQuery`, query, strings.Join(scopeList, ","))
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		result, err := e.azureClient().ExecuteResourceGraphQuery(e.ctx, query, resourceGraphOptions)
+		result, err := e.providers.ResourceGraph.Query(e.ctx, query, resourceGraphOptions.Subscriptions, resourceGraphOptions.ManagementGroups)
 		if err != nil {
 			return nil, err
 		}
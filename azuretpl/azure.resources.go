@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"log/slog"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
-	"github.com/webdevops/go-common/azuresdk/armclient"
 	"github.com/webdevops/go-common/utils/to"
 )
 
@@ -34,61 +32,18 @@ func (e *AzureTemplateExecutor) azResourceList(scope string, opts ...string) (in
 
 	cacheKey := generateCacheKey(`azResourceList`, scope, filter)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		scopeInfo, err := armclient.ParseResourceId(scope)
+		result, err := e.providers.Resource.ListResources(e.ctx, scope, filter)
 		if err != nil {
-			return nil, fmt.Errorf(`unable to parse Azure resourceID '%v': %w`, scope, err)
-		}
-
-		client, err := armresources.NewClient(scopeInfo.Subscription, e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
-		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf(`unable to list Azure resources for scope '%v': %w`, scope, err)
 		}
 
 		ret := []interface{}{}
-		if scopeInfo.ResourceGroup != "" {
-			// list by ResourceGroup
-			options := armresources.ClientListByResourceGroupOptions{}
-			if filter != "" {
-				options.Filter = to.StringPtr(filter)
-			}
-
-			pager := client.NewListByResourceGroupPager(scopeInfo.ResourceGroup, &options)
-			for pager.More() {
-				result, err := pager.NextPage(e.ctx)
-				if err != nil {
-					panic(err)
-				}
-
-				for _, resource := range result.Value {
-					resourceData, err := transformToInterface(resource)
-					if err != nil {
-						return nil, fmt.Errorf(`unable to transform Azure resource '%v': %w`, to.String(resource.ID), err)
-					}
-					ret = append(ret, resourceData)
-				}
-			}
-		} else {
-			// list by Subscription
-			options := armresources.ClientListOptions{}
-			if filter != "" {
-				options.Filter = to.StringPtr(filter)
-			}
-
-			pager := client.NewListPager(&options)
-			for pager.More() {
-				result, err := pager.NextPage(e.ctx)
-				if err != nil {
-					panic(err)
-				}
-
-				for _, resource := range result.Value {
-					resourceData, err := transformToInterface(resource)
-					if err != nil {
-						return nil, fmt.Errorf(`unable to transform Azure resource '%v': %w`, to.String(resource.ID), err)
-					}
-					ret = append(ret, resourceData)
-				}
+		for _, resource := range result {
+			resourceData, err := transformToInterface(resource)
+			if err != nil {
+				return nil, fmt.Errorf(`unable to transform Azure resource '%v': %w`, to.String(resource.ID), err)
 			}
+			ret = append(ret, resourceData)
 		}
 
 		return ret, nil
@@ -2,12 +2,10 @@ package azuretpl
 
 import (
 	"fmt"
-	"io"
 	"log/slog"
+	"strings"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
-	"github.com/webdevops/go-common/azuresdk/armclient"
 )
 
 // azStorageAccountAccessKeys fetches container blob from StorageAccount
@@ -20,57 +18,67 @@ func (e *AzureTemplateExecutor) azStorageAccountAccessKeys(resourceID string) (i
 
 	cacheKey := generateCacheKey(`azStorageAccountAccessKeys`, resourceID)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		resourceInfo, err := armclient.ParseResourceId(resourceID)
+		result, err := e.providers.Storage.ListAccountKeys(e.ctx, resourceID)
 		if err != nil {
-			return nil, fmt.Errorf(`unable to parse Azure resourceID '%v': %w`, resourceID, err)
+			return nil, fmt.Errorf(`unable to fetch Azure StorageAccount accesskeys '%v': %w`, resourceID, err)
 		}
 
-		client, err := armstorage.NewAccountsClient(resourceInfo.Subscription, e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
-		if err != nil {
-			return nil, err
+		return transformToInterface(result)
+	})
+}
+
+// buildStorageBlobUrl builds Azure StorageAccount blob url in case value is supplied as storageaccount/container/blob only
+func (e *AzureTemplateExecutor) buildStorageBlobUrl(containerBlobUrl string) (string, error) {
+	// do not build blob url in lint mode
+	if e.LintMode {
+		return containerBlobUrl, nil
+	}
+
+	// blob url generation (if only storageaccount name is specified)
+	if !strings.HasPrefix(strings.ToLower(containerBlobUrl), "https://") {
+		storageAccount, blobPath, found := strings.Cut(containerBlobUrl, "/")
+		if !found {
+			return containerBlobUrl, fmt.Errorf(`invalid Azure StorageAccount blob "%s", expected url or storageaccount/container/blob`, containerBlobUrl)
 		}
 
-		result, err := client.ListKeys(e.ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+		url, err := e.buildCloudHostUrl(storageAccount+".blob", func(endpoints CloudEndpoints) string {
+			return endpoints.StorageEndpointSuffix
+		}, "storageEndpointSuffix")
 		if err != nil {
-			return nil, err
+			return containerBlobUrl, err
 		}
+		containerBlobUrl = url + "/" + blobPath
+	}
 
-		return transformToInterface(result.Keys)
-	})
+	return containerBlobUrl, nil
 }
 
 // azStorageAccountContainerBlob fetches container blob from StorageAccount
 func (e *AzureTemplateExecutor) azStorageAccountContainerBlob(containerBlobUrl string) (interface{}, error) {
+	// azure storageaccount url detection
+	if val, err := e.buildStorageBlobUrl(containerBlobUrl); err == nil {
+		containerBlobUrl = val
+	} else {
+		return nil, err
+	}
+
 	e.logger.Info(`fetching Azure StorageAccount container blob`, slog.String("containerBlobUrl", containerBlobUrl))
 
 	if val, enabled := e.lintResult(); enabled {
 		return val, nil
 	}
 
-	pathUrl, err := azblob.ParseURL(containerBlobUrl)
-	if err != nil {
+	if _, err := azblob.ParseURL(containerBlobUrl); err != nil {
 		return nil, err
 	}
 
 	cacheKey := generateCacheKey(`azStorageAccountContainerBlob`, containerBlobUrl)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		azblobOpts := azblob.ClientOptions{ClientOptions: *e.azureClient().NewAzCoreClientOptions()}
-
-		storageAccountUrl := fmt.Sprintf("%s://%s", pathUrl.Scheme, pathUrl.Host)
-		client, err := azblob.NewClient(storageAccountUrl, e.azureClient().GetCred(), &azblobOpts)
-		if err != nil {
-			return nil, err
-		}
-
-		response, err := client.DownloadStream(e.ctx, pathUrl.ContainerName, pathUrl.BlobName, nil)
+		content, err := e.providers.Storage.DownloadBlob(e.ctx, containerBlobUrl)
 		if err != nil {
 			return nil, err
 		}
 
-		if content, err := io.ReadAll(response.Body); err == nil {
-			return string(content), nil
-		} else {
-			return nil, err
-		}
+		return string(content), nil
 	})
 }
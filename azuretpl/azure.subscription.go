@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log/slog"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
 	"github.com/webdevops/go-common/utils/to"
 )
 
@@ -41,12 +40,7 @@ func (e *AzureTemplateExecutor) azSubscription(subscriptionID ...string) (interf
 
 	cacheKey := generateCacheKey(`azSubscription`, selectedSubscriptionId)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		client, err := armsubscriptions.NewClient(e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
-		if err != nil {
-			return nil, err
-		}
-
-		resource, err := client.Get(e.ctx, selectedSubscriptionId, nil)
+		resource, err := e.providers.Resource.GetSubscription(e.ctx, selectedSubscriptionId)
 		if err != nil {
 			return nil, fmt.Errorf(`unable to fetch Azure subscription '%v': %w`, selectedSubscriptionId, err)
 		}
@@ -69,26 +63,18 @@ func (e *AzureTemplateExecutor) azSubscriptionList() (interface{}, error) {
 
 	cacheKey := generateCacheKey(`azSubscriptionList`)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		client, err := armsubscriptions.NewClient(e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
+		result, err := e.providers.Resource.ListSubscriptions(e.ctx)
 		if err != nil {
-			panic(err.Error())
+			return nil, fmt.Errorf(`unable to list Azure subscriptions: %w`, err)
 		}
 
-		pager := client.NewListPager(nil)
 		var ret []interface{}
-		for pager.More() {
-			result, err := pager.NextPage(e.ctx)
+		for _, subscription := range result {
+			subscriptionData, err := transformToInterface(subscription)
 			if err != nil {
-				panic(err)
-			}
-
-			for _, subscription := range result.Value {
-				subscriptionData, err := transformToInterface(subscription)
-				if err != nil {
-					return nil, fmt.Errorf(`unable to transform Azure subscription '%v': %w`, to.String(subscription.SubscriptionID), err)
-				}
-				ret = append(ret, subscriptionData)
+				return nil, fmt.Errorf(`unable to transform Azure subscription '%v': %w`, to.String(subscription.SubscriptionID), err)
 			}
+			ret = append(ret, subscriptionData)
 		}
 
 		return ret, nil
@@ -1,15 +1,100 @@
 package azuretpl
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
 )
 
+type (
+	// resultCache caches results of template functions and joins identical in-flight calls,
+	// executors created by New are sharing one cache (eg. for multiple template files)
+	resultCache struct {
+		*cache.Cache
+
+		inFlightCalls     map[string]*inFlightCall
+		inFlightCallsLock sync.Mutex
+	}
+
+	// inFlightCall is a template function call which is currently fetched
+	inFlightCall struct {
+		cache *resultCache
+
+		wg  sync.WaitGroup
+		val interface{}
+		err error
+	}
+)
+
 var (
-	globalCache *cache.Cache
+	globalCache = newResultCache()
+
+	// secretFunctions are template functions (by cache key prefix) which return secret values,
+	// their results are redacted in fixtures and encrypted in the persistent cache
+	secretFunctions = map[string]bool{
+		`azKeyVaultSecret`:                true,
+		`azKeyVaultSecretVersions`:        true,
+		`azKeyVaultCertificateWithKey`:    true,
+		`azStorageAccountAccessKeys`:      true,
+		`azRedisAccessKeys`:               true,
+		`azManagedClusterUserCredentials`: true,
+		`azAppConfigSetting`:              true,
+	}
 )
 
-func init() {
-	globalCache = cache.New(15*time.Minute, 1*time.Minute)
+func newResultCache() *resultCache {
+	return &resultCache{
+		Cache:         cache.New(15*time.Minute, 1*time.Minute),
+		inFlightCalls: map[string]*inFlightCall{},
+	}
+}
+
+// startInFlightCall registers a new call for cacheKey,
+// returns the already running call and true if there is an identical call in-flight
+func (c *resultCache) startInFlightCall(cacheKey string) (*inFlightCall, bool) {
+	c.inFlightCallsLock.Lock()
+	defer c.inFlightCallsLock.Unlock()
+
+	if call, ok := c.inFlightCalls[cacheKey]; ok {
+		return call, true
+	}
+
+	call := &inFlightCall{cache: c}
+	call.wg.Add(1)
+	c.inFlightCalls[cacheKey] = call
+	return call, false
+}
+
+// wait waits until the call is finished and returns its result
+func (c *inFlightCall) wait() (interface{}, error) {
+	c.wg.Wait()
+	return c.val, c.err
+}
+
+// run executes callback and finishes the call with its result,
+// waiting callers are also released if callback panics (the panic is returned as error)
+func (c *inFlightCall) run(cacheKey string, callback func() (interface{}, error)) (val interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			val = nil
+			err = fmt.Errorf(`panic while fetching "%[1]v": %[2]v`, cacheKey, r)
+		}
+		c.finish(cacheKey, val, err)
+	}()
+
+	return callback()
+}
+
+// finish stores the result and releases all waiting callers
+func (c *inFlightCall) finish(cacheKey string, val interface{}, err error) {
+	c.val = val
+	c.err = err
+
+	c.cache.inFlightCallsLock.Lock()
+	delete(c.cache.inFlightCalls, cacheKey)
+	c.cache.inFlightCallsLock.Unlock()
+
+	c.wg.Done()
 }
@@ -0,0 +1,249 @@
+package azuretpl
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	PersistentCacheVersion       = 1
+	PersistentCacheFileExtension = ".json"
+)
+
+type (
+	// PersistentCacheEntry is the on-disk format of one cached template function result
+	PersistentCacheEntry struct {
+		Version   int             `json:"version"`
+		Key       string          `json:"key"`
+		Function  string          `json:"function"`
+		Created   time.Time       `json:"created"`
+		Expires   time.Time       `json:"expires"`
+		Encrypted bool            `json:"encrypted"`
+		Value     json.RawMessage `json:"value,omitempty"`
+		Data      []byte          `json:"data,omitempty"`
+	}
+
+	// PersistentCache stores template function results on disk,
+	// results of secret functions are only stored encrypted
+	PersistentCache struct {
+		dir        string
+		encryption cipher.AEAD
+	}
+)
+
+// NewPersistentCache creates persistent cache inside dir, secret results are only cached if encryptionKey is set
+func NewPersistentCache(dir string, encryptionKey string) (*PersistentCache, error) {
+	c := &PersistentCache{
+		dir: filepath.Clean(dir),
+	}
+
+	if encryptionKey != "" {
+		key := sha256.Sum256([]byte(encryptionKey))
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			return nil, fmt.Errorf(`unable to init cache encryption: %w`, err)
+		}
+
+		c.encryption, err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf(`unable to init cache encryption: %w`, err)
+		}
+	}
+
+	return c, nil
+}
+
+// filePath returns the path of the cache file for cacheKey (cache keys can contain secret names or urls, so they are hashed)
+func (c *PersistentCache) filePath(cacheKey string) string {
+	hash := sha256.Sum256([]byte(cacheKey))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+PersistentCacheFileExtension)
+}
+
+// Get returns cached result for cacheKey if it exists and is not expired
+func (c *PersistentCache) Get(cacheKey string) (interface{}, bool) {
+	entry, err := c.readEntry(c.filePath(cacheKey))
+	if err != nil || time.Now().After(entry.Expires) {
+		return nil, false
+	}
+
+	value := []byte(entry.Value)
+	if entry.Encrypted {
+		if value, err = c.decrypt(cacheKey, entry.Data); err != nil {
+			return nil, false
+		}
+	} else if entry.Key != cacheKey {
+		return nil, false
+	}
+
+	var ret interface{}
+	if err := json.Unmarshal(value, &ret); err != nil {
+		return nil, false
+	}
+
+	return ret, true
+}
+
+// Set stores result for cacheKey with ttl, results of secret functions are skipped if no encryption key is set
+func (c *PersistentCache) Set(cacheKey string, val interface{}, ttl time.Duration) error {
+	funcName := cacheKeyFunctionName(cacheKey)
+	secret := secretFunctions[funcName]
+	if secret && c.encryption == nil {
+		return nil
+	}
+
+	value, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Errorf(`unable to marshal cache entry "%v": %w`, cacheKey, err)
+	}
+
+	entry := PersistentCacheEntry{
+		Version:  PersistentCacheVersion,
+		Key:      cacheKey,
+		Function: funcName,
+		Created:  time.Now(),
+		Expires:  time.Now().Add(ttl),
+	}
+
+	if secret {
+		entry.Encrypted = true
+		if entry.Data, err = c.encrypt(cacheKey, value); err != nil {
+			return fmt.Errorf(`unable to encrypt cache entry "%v": %w`, cacheKey, err)
+		}
+		// do not expose secret names or urls in plain text
+		entry.Key = ""
+	} else {
+		entry.Value = value
+	}
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf(`unable to marshal cache entry "%v": %w`, cacheKey, err)
+	}
+
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf(`unable to create cache directory "%v": %w`, c.dir, err)
+	}
+
+	// write to temporary file first to avoid partial written files when running in parallel
+	tmpFile, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf(`unable to write cache entry: %w`, err)
+	}
+	defer os.Remove(tmpFile.Name()) // nolint: errcheck
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close() // nolint: errcheck
+		return fmt.Errorf(`unable to write cache entry: %w`, err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf(`unable to write cache entry: %w`, err)
+	}
+
+	return os.Rename(tmpFile.Name(), c.filePath(cacheKey))
+}
+
+// List returns all cache entries (without values)
+func (c *PersistentCache) List() ([]PersistentCacheEntry, error) {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*"+PersistentCacheFileExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	list := []PersistentCacheEntry{}
+	for _, path := range files {
+		entry, err := c.readEntry(path)
+		if err != nil {
+			continue
+		}
+
+		entry.Value = nil
+		entry.Data = nil
+		list = append(list, entry)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Function != list[j].Function {
+			return list[i].Function < list[j].Function
+		}
+		return list[i].Key < list[j].Key
+	})
+
+	return list, nil
+}
+
+// Clear removes all cache entries, returns the number of removed entries
+func (c *PersistentCache) Clear() (int, error) {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*"+PersistentCacheFileExtension))
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, path := range files {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return count, fmt.Errorf(`unable to remove cache entry "%v": %w`, path, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func (c *PersistentCache) readEntry(path string) (PersistentCacheEntry, error) {
+	entry := PersistentCacheEntry{}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return entry, err
+	}
+
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return entry, err
+	}
+
+	if entry.Version != PersistentCacheVersion {
+		return entry, fmt.Errorf(`unsupported cache entry version "%v"`, entry.Version)
+	}
+
+	return entry, nil
+}
+
+// encrypt encrypts value, the cache key is used as additional data so entries cannot be swapped
+func (c *PersistentCache) encrypt(cacheKey string, value []byte) ([]byte, error) {
+	nonce := make([]byte, c.encryption.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return c.encryption.Seal(nonce, nonce, value, []byte(cacheKey)), nil
+}
+
+func (c *PersistentCache) decrypt(cacheKey string, data []byte) ([]byte, error) {
+	if c.encryption == nil {
+		return nil, errors.New(`no encryption key set`)
+	}
+
+	nonceSize := c.encryption.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New(`invalid encrypted data`)
+	}
+
+	return c.encryption.Open(nil, data[:nonceSize], data[nonceSize:], []byte(cacheKey))
+}
+
+// cacheKeyFunctionName returns the template function name of a cache key
+func cacheKeyFunctionName(cacheKey string) string {
+	return strings.SplitN(cacheKey, ":", 2)[0]
+}
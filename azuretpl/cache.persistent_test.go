@@ -0,0 +1,150 @@
+package azuretpl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPersistentCache(t *testing.T) {
+	secretKey := generateCacheKey(`azKeyVaultSecret`, "https://vault.vault.azure.net", "password", "")
+	secretValue := map[string]interface{}{"value": "s3cr3t"}
+
+	resourceKey := generateCacheKey(`azResource`, "/subscriptions/0000", "2022-01-01")
+	resourceValue := map[string]interface{}{"name": "resource"}
+
+	tests := []struct {
+		name           string
+		encryptionKey  string
+		readKey        string
+		cacheKey       string
+		value          interface{}
+		ttl            time.Duration
+		expectedCached bool
+	}{
+		{name: "plain result", cacheKey: resourceKey, value: resourceValue, ttl: time.Hour, expectedCached: true},
+		{name: "expired result", cacheKey: resourceKey, value: resourceValue, ttl: -time.Second, expectedCached: false},
+		{name: "secret without encryption key", cacheKey: secretKey, value: secretValue, ttl: time.Hour, expectedCached: false},
+		{name: "encrypted secret", encryptionKey: "key", readKey: "key", cacheKey: secretKey, value: secretValue, ttl: time.Hour, expectedCached: true},
+		{name: "encrypted secret with other key", encryptionKey: "key", readKey: "other", cacheKey: secretKey, value: secretValue, ttl: time.Hour, expectedCached: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			writer, err := NewPersistentCache(dir, test.encryptionKey)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := writer.Set(test.cacheKey, test.value, test.ttl); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// secret values and names are never stored in plain text
+			files, _ := filepath.Glob(filepath.Join(dir, "*"))
+			for _, path := range files {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strings.Contains(string(content), "s3cr3t") || strings.Contains(string(content), "password") {
+					t.Errorf("cache file contains secret in plain text: %s", content)
+				}
+			}
+
+			reader, err := NewPersistentCache(dir, test.readKey)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			val, cached := reader.Get(test.cacheKey)
+			if cached != test.expectedCached {
+				t.Fatalf("expected cached=%v, got %v", test.expectedCached, cached)
+			}
+
+			if cached && !reflect.DeepEqual(val, test.value) {
+				t.Errorf("expected %v, got %v", test.value, val)
+			}
+		})
+	}
+}
+
+func TestPersistentCacheEncryptedEntriesCannotBeSwapped(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewPersistentCache(dir, "key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	firstKey := generateCacheKey(`azKeyVaultSecret`, "https://vault.vault.azure.net", "first", "")
+	secondKey := generateCacheKey(`azKeyVaultSecret`, "https://vault.vault.azure.net", "second", "")
+
+	if err := c.Set(firstKey, "first", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// replace entry of second secret with entry of first secret
+	content, err := os.ReadFile(c.filePath(firstKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.filePath(secondKey), content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, cached := c.Get(secondKey); cached {
+		t.Errorf("expected swapped entry to be rejected")
+	}
+}
+
+func TestPersistentCacheListAndClear(t *testing.T) {
+	c, err := NewPersistentCache(t.TempDir(), "key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, cacheKey := range []string{
+		generateCacheKey(`azSubscription`, "0000"),
+		generateCacheKey(`azKeyVaultSecret`, "https://vault.vault.azure.net", "password", ""),
+		generateCacheKey(`azResource`, "/subscriptions/0000", "2022-01-01"),
+	} {
+		if err := c.Set(cacheKey, "value", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := c.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := []string{}
+	for _, entry := range list {
+		result = append(result, entry.Function+":"+entry.Key)
+		if entry.Value != nil || entry.Data != nil {
+			t.Errorf("expected entry without value: %+v", entry)
+		}
+	}
+	expected := []string{
+		"azKeyVaultSecret:",
+		"azResource:azResource:/subscriptions/0000:2022-01-01",
+		"azSubscription:azSubscription:0000",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+
+	count, err := c.Clear()
+	if err != nil || count != 3 {
+		t.Errorf("expected 3 removed entries, got %v (%v)", count, err)
+	}
+
+	if list, _ := c.List(); len(list) != 0 {
+		t.Errorf("expected empty cache, got %v entries", len(list))
+	}
+}
@@ -0,0 +1,75 @@
+package azuretpl
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInFlightCallRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		callback    func() (interface{}, error)
+		expectedVal interface{}
+		expectedErr bool
+	}{
+		{
+			name:        "result",
+			callback:    func() (interface{}, error) { return "value", nil },
+			expectedVal: "value",
+		},
+		{
+			name:        "error",
+			callback:    func() (interface{}, error) { return nil, errors.New("failed") },
+			expectedErr: true,
+		},
+		{
+			name:        "panic",
+			callback:    func() (interface{}, error) { panic("failed") },
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newResultCache()
+
+			call, inFlight := c.startInFlightCall("key")
+			if inFlight {
+				t.Fatalf("expected new call")
+			}
+
+			waiter, inFlight := c.startInFlightCall("key")
+			if !inFlight || waiter != call {
+				t.Fatalf("expected in-flight call")
+			}
+
+			done := make(chan error)
+			go func() {
+				_, err := waiter.wait()
+				done <- err
+			}()
+
+			val, err := call.run("key", test.callback)
+			if (err != nil) != test.expectedErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if val != test.expectedVal {
+				t.Errorf("expected %v, got %v", test.expectedVal, val)
+			}
+
+			select {
+			case waitErr := <-done:
+				if (waitErr != nil) != test.expectedErr {
+					t.Errorf("unexpected error for waiting call: %v", waitErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("waiting call was not released")
+			}
+
+			if _, inFlight := c.startInFlightCall("key"); inFlight {
+				t.Errorf("expected call to be removed from in-flight calls")
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package azuretpl
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha1" // #nosec G505 thumbprints are SHA-1 by definition
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
+)
+
+const (
+	KeyVaultContentTypePem    = "application/x-pem-file"
+	KeyVaultContentTypePkcs12 = "application/x-pkcs12"
+)
+
+type (
+	// kubernetesSecret is a Kubernetes Secret manifest
+	kubernetesSecret struct {
+		ApiVersion string                   `json:"apiVersion"`
+		Kind       string                   `json:"kind"`
+		Metadata   kubernetesSecretMetadata `json:"metadata"`
+		Type       string                   `json:"type"`
+		Data       map[string][]byte        `json:"data"`
+	}
+
+	kubernetesSecretMetadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace,omitempty"`
+	}
+
+	// certificateBundle is a decoded certificate with chain and (optional) private key
+	certificateBundle struct {
+		Leaf       *x509.Certificate
+		Chain      []*x509.Certificate
+		PrivateKey crypto.PrivateKey
+	}
+)
+
+// decodeCertificateBundle decodes a certificate bundle (PEM or base64 encoded PKCS#12) as stored in Azure KeyVault secrets,
+// the format is detected by content if contentType is empty
+func decodeCertificateBundle(contentType, value string) (*certificateBundle, error) {
+	switch {
+	case strings.EqualFold(contentType, KeyVaultContentTypePkcs12):
+		return decodePkcs12Bundle(value, "")
+	case strings.EqualFold(contentType, KeyVaultContentTypePem):
+		return decodePemBundle([]byte(value))
+	case contentType == "" && strings.Contains(value, "-----BEGIN "):
+		return decodePemBundle([]byte(value))
+	case contentType == "":
+		return decodePkcs12Bundle(value, "")
+	}
+
+	return nil, fmt.Errorf(`unsupported certificate content type "%v"`, contentType)
+}
+
+// decodePkcs12Bundle decodes base64 encoded PKCS#12 (pfx) data, certificates exported by Azure KeyVault use an empty password
+func decodePkcs12Bundle(value, password string) (*certificateBundle, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf(`unable to decode PKCS#12 data as base64: %w`, err)
+	}
+
+	privateKey, leaf, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to decode PKCS#12 data: %w`, err)
+	}
+
+	return &certificateBundle{
+		Leaf:       leaf,
+		Chain:      chain,
+		PrivateKey: privateKey,
+	}, nil
+}
+
+// decodePemBundle decodes all certificates and the private key from PEM data,
+// the leaf is the certificate matching the private key (or the first certificate if there is no private key)
+func decodePemBundle(data []byte) (*certificateBundle, error) {
+	certificates := []*x509.Certificate{}
+	var privateKey crypto.PrivateKey
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "CERTIFICATE":
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf(`unable to parse PEM certificate: %w`, err)
+			}
+			certificates = append(certificates, certificate)
+		case "PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY":
+			if privateKey != nil {
+				return nil, errors.New(`found multiple private keys in PEM data`)
+			}
+
+			key, err := parsePemPrivateKey(block)
+			if err != nil {
+				return nil, err
+			}
+			privateKey = key
+		}
+	}
+
+	if len(certificates) == 0 {
+		return nil, errors.New(`no certificate found in PEM data`)
+	}
+
+	ret := &certificateBundle{PrivateKey: privateKey}
+	for _, certificate := range certificates {
+		if ret.Leaf == nil && (privateKey == nil || publicKeyMatchesPrivateKey(certificate.PublicKey, privateKey)) {
+			ret.Leaf = certificate
+			continue
+		}
+		ret.Chain = append(ret.Chain, certificate)
+	}
+
+	if ret.Leaf == nil {
+		return nil, errors.New(`no certificate matching the private key found in PEM data`)
+	}
+
+	return ret, nil
+}
+
+func parsePemPrivateKey(block *pem.Block) (crypto.PrivateKey, error) {
+	var (
+		key crypto.PrivateKey
+		err error
+	)
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf(`unable to parse PEM private key: %w`, err)
+	}
+	return key, nil
+}
+
+func publicKeyMatchesPrivateKey(publicKey crypto.PublicKey, privateKey crypto.PrivateKey) bool {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return false
+	}
+
+	if key, ok := publicKey.(interface{ Equal(crypto.PublicKey) bool }); ok {
+		return key.Equal(signer.Public())
+	}
+	return false
+}
+
+// Certificates returns leaf and chain
+func (b *certificateBundle) Certificates() []*x509.Certificate {
+	return append([]*x509.Certificate{b.Leaf}, b.Chain...)
+}
+
+// PrivateKeyPem returns the private key as PKCS#8 PEM (empty if bundle has no private key)
+func (b *certificateBundle) PrivateKeyPem() (string, error) {
+	if b.PrivateKey == nil {
+		return "", nil
+	}
+
+	data, err := x509.MarshalPKCS8PrivateKey(b.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf(`unable to encode private key: %w`, err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: data})), nil
+}
+
+// Model returns the bundle as template function result
+func (b *certificateBundle) Model() (*models.Certificate, error) {
+	privateKey, err := b.PrivateKeyPem()
+	if err != nil {
+		return nil, err
+	}
+
+	thumbprint := sha1.Sum(b.Leaf.Raw) // #nosec G401 thumbprints are SHA-1 by definition
+
+	return &models.Certificate{
+		Subject:      b.Leaf.Subject.String(),
+		Issuer:       b.Leaf.Issuer.String(),
+		SerialNumber: strings.ToUpper(b.Leaf.SerialNumber.Text(16)),
+		DNSNames:     b.Leaf.DNSNames,
+		NotBefore:    b.Leaf.NotBefore.Unix(),
+		NotAfter:     b.Leaf.NotAfter.Unix(),
+		Thumbprint:   strings.ToUpper(hex.EncodeToString(thumbprint[:])),
+		Certificate:  encodeCertificatesPem(b.Leaf),
+		Chain:        encodeCertificatesPem(b.Chain...),
+		TlsCrt:       encodeCertificatesPem(b.Certificates()...),
+		TlsKey:       privateKey,
+	}, nil
+}
+
+// encodeCertificatesPem encodes certificates as PEM (one block per certificate)
+func encodeCertificatesPem(certificates ...*x509.Certificate) string {
+	buf := bytes.Buffer{}
+	for _, certificate := range certificates {
+		pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}) // nolint: errcheck
+	}
+	return buf.String()
+}
+
+// encodePublicKeyPem encodes public key as PKIX PEM
+func encodePublicKeyPem(publicKey crypto.PublicKey) (string, error) {
+	data, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf(`unable to encode public key: %w`, err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: data})), nil
+}
+
+// certificateFromSecret decodes certificate, chain and private key from Azure KeyVault secret (result of azKeyVaultSecret)
+// or from PEM or base64 encoded PKCS#12 string
+func (e *AzureTemplateExecutor) certificateFromSecret(secret interface{}) (interface{}, error) {
+	if val, enabled := e.lintResult(); enabled {
+		return val, nil
+	}
+
+	bundle, err := certificateBundleFromValue(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.certificateResult(bundle)
+}
+
+// certificateFromPkcs12 decodes certificate, chain and private key from base64 encoded PKCS#12 (pfx) data
+func (e *AzureTemplateExecutor) certificateFromPkcs12(value string, password ...string) (interface{}, error) {
+	if val, enabled := e.lintResult(); enabled {
+		return val, nil
+	}
+
+	bundle, err := decodePkcs12Bundle(value, strings.Join(password, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	return e.certificateResult(bundle)
+}
+
+// certificateFromPem decodes certificate, chain and private key from PEM data
+func (e *AzureTemplateExecutor) certificateFromPem(value string) (interface{}, error) {
+	if val, enabled := e.lintResult(); enabled {
+		return val, nil
+	}
+
+	bundle, err := decodePemBundle([]byte(value))
+	if err != nil {
+		return nil, err
+	}
+
+	return e.certificateResult(bundle)
+}
+
+// kubernetesTlsSecret renders a kubernetes.io/tls Secret manifest (yaml) from certificate with private key
+// (result of certificateFrom*, azKeyVaultCertificateWithKey or azKeyVaultSecret)
+func (e *AzureTemplateExecutor) kubernetesTlsSecret(name string, certificate interface{}, opts ...string) (string, error) {
+	if _, enabled := e.lintResult(); enabled {
+		return "", nil
+	}
+
+	bundle, err := certificateBundleFromValue(certificate)
+	if err != nil {
+		return "", fmt.Errorf(`unable to build kubernetes tls secret "%v": %w`, name, err)
+	}
+
+	if bundle.PrivateKey == nil {
+		return "", fmt.Errorf(`unable to build kubernetes tls secret "%v": certificate has no private key`, name)
+	}
+
+	result, err := bundle.Model()
+	if err != nil {
+		return "", fmt.Errorf(`unable to build kubernetes tls secret "%v": %w`, name, err)
+	}
+	e.handleCertificateKey(result.TlsKey)
+
+	manifest := kubernetesSecret{
+		ApiVersion: "v1",
+		Kind:       "Secret",
+		Metadata: kubernetesSecretMetadata{
+			Name: name,
+		},
+		Type: "kubernetes.io/tls",
+		Data: map[string][]byte{
+			"tls.crt": []byte(result.TlsCrt),
+			"tls.key": []byte(result.TlsKey),
+		},
+	}
+	if len(opts) >= 1 {
+		manifest.Metadata.Namespace = opts[0]
+	}
+
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return "", fmt.Errorf(`unable to build kubernetes tls secret "%v": %w`, name, err)
+	}
+
+	return string(data), nil
+}
+
+func (e *AzureTemplateExecutor) certificateResult(bundle *certificateBundle) (interface{}, error) {
+	result, err := bundle.Model()
+	if err != nil {
+		return nil, err
+	}
+	e.handleCertificateKey(result.TlsKey)
+
+	return transformToInterface(result)
+}
+
+// handleCertificateKey masks the decoded private key (the source secret is already masked, but not the PEM encoded key),
+// the base64 encoded key is masked as well as it's used inside kubernetes secrets (see kubernetesTlsSecret)
+func (e *AzureTemplateExecutor) handleCertificateKey(privateKey string) {
+	if privateKey == "" {
+		return
+	}
+
+	// also registers the base64 encoded key
+	registerSecretValue(privateKey)
+	e.handleCicdMaskSecretLines(privateKey)
+	e.handleCicdMaskSecret(base64.StdEncoding.EncodeToString([]byte(privateKey)))
+}
+
+// certificateBundleFromValue decodes certificate bundle from template value: string (PEM or base64 PKCS#12),
+// certificate object with tls.crt and tls.key or Azure KeyVault secret object (value and contentType)
+func certificateBundleFromValue(val interface{}) (*certificateBundle, error) {
+	switch v := val.(type) {
+	case string:
+		return decodeCertificateBundle("", v)
+	case map[string]interface{}:
+		if tlsCrt, ok := v["tls.crt"].(string); ok && tlsCrt != "" {
+			tlsKey, _ := v["tls.key"].(string)
+			return decodePemBundle([]byte(tlsCrt + "\n" + tlsKey))
+		}
+
+		if value, ok := v["value"].(string); ok {
+			contentType, _ := v["contentType"].(string)
+			return decodeCertificateBundle(contentType, value)
+		}
+
+		if certificate, ok := v["certificate"].(string); ok && certificate != "" {
+			return decodePemBundle([]byte(certificate))
+		}
+	}
+
+	return nil, fmt.Errorf(`unable to decode certificate from %T, expected PEM or PKCS#12 string, secret or certificate object`, val)
+}
@@ -2,6 +2,7 @@ package azuretpl
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -46,6 +47,37 @@ func (e *AzureTemplateExecutor) handleCicdMaskSecret(val string) {
 	}
 }
 
+// handleCicdMaskSecretLines masks every line of a multi line secret (eg. PEM private key), PEM armor lines are not masked
+func (e *AzureTemplateExecutor) handleCicdMaskSecretLines(val string) {
+	for _, line := range strings.Split(val, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < secretMaskMinLength || strings.HasPrefix(line, "-----") {
+			continue
+		}
+		e.handleCicdMaskSecret(line)
+	}
+}
+
+// handleCicdMaskSecretResult masks all secret values (eg. "value" fields) inside a template function result
+func (e *AzureTemplateExecutor) handleCicdMaskSecretResult(val interface{}) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if secret, ok := item.(string); ok && key == "value" && secret != "" {
+				e.handleCicdMaskSecret(secret)
+			} else if ok && secretResultFields[key] && strings.Contains(secret, "\n") {
+				e.handleCicdMaskSecretLines(secret)
+			} else {
+				e.handleCicdMaskSecretResult(item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			e.handleCicdMaskSecretResult(item)
+		}
+	}
+}
+
 func (e *AzureTemplateExecutor) handleCicdWarning(err error) string {
 	workflowLogMsg := ""
 
@@ -80,10 +112,23 @@ func (e *AzureTemplateExecutor) handleCicdError(err error) error {
 	// only show first line of error (could be a multi line error message)
 	workflowLogError := strings.SplitN(err.Error(), "\n", 2)[0]
 
+	location := TemplateLocation{File: e.currentPath}
+	var templateErr *TemplateError
+	if errors.As(err, &templateErr) {
+		location = templateErr.TemplateLocation
+	}
+
 	switch {
 	case os.Getenv("SYSTEM_TEAMFOUNDATIONSERVERURI") != "":
 		// Azure DevOps
-		workflowLogMsg = fmt.Sprintf(`##vso[task.logissue type=error;sourcepath=%v]%v`, e.currentPath, workflowLogError)
+		properties := fmt.Sprintf(`sourcepath=%v`, location.File)
+		if location.Line > 0 {
+			properties += fmt.Sprintf(`;linenumber=%d`, location.Line)
+		}
+		if location.Column > 0 {
+			properties += fmt.Sprintf(`;columnnumber=%d`, location.Column)
+		}
+		workflowLogMsg = fmt.Sprintf(`##vso[task.logissue type=error;%v]%v`, properties, workflowLogError)
 	case os.Getenv("GITLAB_CI") != "":
 		// GitLab
 		// no error logging available
@@ -92,7 +137,14 @@ func (e *AzureTemplateExecutor) handleCicdError(err error) error {
 		// no error logging available
 	case os.Getenv("GITHUB_ACTION") != "":
 		// GitHub
-		workflowLogMsg = fmt.Sprintf(`::error file=%v,title=helm-azure-tpl::%v`, e.currentPath, workflowLogError)
+		properties := fmt.Sprintf(`file=%v`, location.File)
+		if location.Line > 0 {
+			properties += fmt.Sprintf(`,line=%d`, location.Line)
+		}
+		if location.Column > 0 {
+			properties += fmt.Sprintf(`,col=%d`, location.Column)
+		}
+		workflowLogMsg = fmt.Sprintf(`::error %v,title=helm-azure-tpl::%v`, properties, workflowLogError)
 	}
 
 	if workflowLogMsg != "" {
@@ -0,0 +1,175 @@
+package azuretpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/webdevops/go-common/azuresdk/cloudconfig"
+)
+
+type (
+	// CloudEndpoints are the endpoints and DNS suffixes of an Azure cloud,
+	// custom clouds (eg. Azure Stack) are loaded from json files using the format of Azure environment files
+	CloudEndpoints struct {
+		Name                      string `json:"name"`
+		ResourceManagerEndpoint   string `json:"resourceManagerEndpoint"`
+		ActiveDirectoryEndpoint   string `json:"activeDirectoryEndpoint"`
+		TokenAudience             string `json:"tokenAudience"`
+		MicrosoftGraphEndpoint    string `json:"microsoftGraphEndpoint"`
+		KeyVaultDNSSuffix         string `json:"keyVaultDNSSuffix"`
+		StorageEndpointSuffix     string `json:"storageEndpointSuffix"`
+		AppConfigurationDNSSuffix string `json:"appConfigurationDNSSuffix"`
+	}
+)
+
+var (
+	// knownCloudEndpoints are the endpoints of the Azure clouds supported by the Azure SDK
+	knownCloudEndpoints = map[cloudconfig.CloudName]CloudEndpoints{
+		cloudconfig.AzurePublicCloud: {
+			Name:                      string(cloudconfig.AzurePublicCloud),
+			MicrosoftGraphEndpoint:    "https://graph.microsoft.com",
+			KeyVaultDNSSuffix:         "vault.azure.net",
+			StorageEndpointSuffix:     "core.windows.net",
+			AppConfigurationDNSSuffix: "azconfig.io",
+		},
+		cloudconfig.AzureChinaCloud: {
+			Name:                      string(cloudconfig.AzureChinaCloud),
+			MicrosoftGraphEndpoint:    "https://microsoftgraph.chinacloudapi.cn",
+			KeyVaultDNSSuffix:         "vault.azure.cn",
+			StorageEndpointSuffix:     "core.chinacloudapi.cn",
+			AppConfigurationDNSSuffix: "azconfig.azure.cn",
+		},
+		cloudconfig.AzureGovernmentCloud: {
+			Name:                      string(cloudconfig.AzureGovernmentCloud),
+			MicrosoftGraphEndpoint:    "https://graph.microsoft.us",
+			KeyVaultDNSSuffix:         "vault.usgovcloudapi.net",
+			StorageEndpointSuffix:     "core.usgovcloudapi.net",
+			AppConfigurationDNSSuffix: "azconfig.azure.us",
+		},
+	}
+
+	customCloudEndpoints     = map[string]CloudEndpoints{}
+	customCloudEndpointsLock sync.Mutex
+)
+
+// loadCloudEndpointsFile reads endpoints of a custom cloud from json file, files are only read once
+func loadCloudEndpointsFile(path string) (CloudEndpoints, error) {
+	customCloudEndpointsLock.Lock()
+	defer customCloudEndpointsLock.Unlock()
+
+	if endpoints, exists := customCloudEndpoints[path]; exists {
+		return endpoints, nil
+	}
+
+	content, err := os.ReadFile(path) // #nosec G304 cloud config file is set by user
+	if err != nil {
+		return CloudEndpoints{}, fmt.Errorf(`unable to read Azure cloud config "%v": %w`, path, err)
+	}
+
+	endpoints := CloudEndpoints{}
+	if err := json.Unmarshal(content, &endpoints); err != nil {
+		return CloudEndpoints{}, fmt.Errorf(`unable to parse Azure cloud config "%v": %w`, path, err)
+	}
+
+	customCloudEndpoints[path] = endpoints
+	return endpoints, nil
+}
+
+// cloudEndpoints returns the endpoints of the current Azure cloud,
+// values of the cloud config (see --cloud.config) are overriding the known endpoints of the same cloud
+func (e *AzureTemplateExecutor) cloudEndpoints() (CloudEndpoints, error) {
+	cloudName, err := e.cloudName()
+	if err != nil {
+		return CloudEndpoints{}, err
+	}
+
+	ret, exists := knownCloudEndpoints[cloudName]
+	if !exists {
+		ret = CloudEndpoints{Name: string(cloudName)}
+	}
+
+	if e.opts.Cloud.ConfigFile != "" {
+		custom, err := loadCloudEndpointsFile(e.opts.Cloud.ConfigFile)
+		if err != nil {
+			return CloudEndpoints{}, err
+		}
+
+		if custom.Name != "" && !strings.EqualFold(custom.Name, ret.Name) {
+			// another cloud (eg. Azure Stack), only endpoints of the cloud config are used
+			ret = CloudEndpoints{Name: custom.Name}
+		}
+		if custom.MicrosoftGraphEndpoint != "" {
+			ret.MicrosoftGraphEndpoint = custom.MicrosoftGraphEndpoint
+		}
+		if custom.KeyVaultDNSSuffix != "" {
+			ret.KeyVaultDNSSuffix = custom.KeyVaultDNSSuffix
+		}
+		if custom.StorageEndpointSuffix != "" {
+			ret.StorageEndpointSuffix = custom.StorageEndpointSuffix
+		}
+		if custom.AppConfigurationDNSSuffix != "" {
+			ret.AppConfigurationDNSSuffix = custom.AppConfigurationDNSSuffix
+		}
+	}
+
+	return ret, nil
+}
+
+// cloudConfiguration applies the Azure Resource Manager and authentication endpoints of the cloud config (see --cloud.config)
+// to the Azure SDK cloud configuration, used for the Azure Resource Manager clients and credentials
+func (e *AzureTemplateExecutor) cloudConfiguration(base cloud.Configuration) (cloud.Configuration, error) {
+	if e.opts.Cloud.ConfigFile == "" {
+		return base, nil
+	}
+
+	custom, err := loadCloudEndpointsFile(e.opts.Cloud.ConfigFile)
+	if err != nil {
+		return base, err
+	}
+
+	ret := cloud.Configuration{
+		ActiveDirectoryAuthorityHost: base.ActiveDirectoryAuthorityHost,
+		Services:                     map[cloud.ServiceName]cloud.ServiceConfiguration{},
+	}
+	for name, service := range base.Services {
+		ret.Services[name] = service
+	}
+
+	if custom.ActiveDirectoryEndpoint != "" {
+		ret.ActiveDirectoryAuthorityHost = custom.ActiveDirectoryEndpoint
+	}
+
+	if custom.ResourceManagerEndpoint != "" {
+		audience := custom.TokenAudience
+		if audience == "" {
+			audience = custom.ResourceManagerEndpoint
+		}
+
+		ret.Services[cloud.ResourceManager] = cloud.ServiceConfiguration{
+			Endpoint: custom.ResourceManagerEndpoint,
+			Audience: audience,
+		}
+	}
+
+	return ret, nil
+}
+
+// buildCloudHostUrl builds the url of a data-plane host (eg. https://name.vault.azure.net) using the DNS suffix of the current cloud,
+// suffixName is the name of the setting in the cloud config (for error messages)
+func (e *AzureTemplateExecutor) buildCloudHostUrl(name string, suffix func(CloudEndpoints) string, suffixName string) (string, error) {
+	endpoints, err := e.cloudEndpoints()
+	if err != nil {
+		return "", err
+	}
+
+	dnsSuffix := strings.Trim(suffix(endpoints), ".")
+	if dnsSuffix == "" {
+		return "", fmt.Errorf(`cannot build url for "%s", Azure cloud "%s" has no %s, please use full url or set it in the cloud config (--cloud.config)`, name, endpoints.Name, suffixName)
+	}
+
+	return fmt.Sprintf(`https://%s.%s`, name, dnsSuffix), nil
+}
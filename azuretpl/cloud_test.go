@@ -0,0 +1,84 @@
+package azuretpl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+)
+
+func TestCloudConfiguration(t *testing.T) {
+	tests := []struct {
+		name                 string
+		config               string
+		expectedAuthority    string
+		expectedArmEndpoint  string
+		expectedArmAudience  string
+		expectedServiceCount int
+	}{
+		{
+			name:                 "data plane endpoints only",
+			config:               `{"name": "AzureCloud", "keyVaultDNSSuffix": "vault.example.com"}`,
+			expectedAuthority:    cloud.AzurePublic.ActiveDirectoryAuthorityHost,
+			expectedArmEndpoint:  cloud.AzurePublic.Services[cloud.ResourceManager].Endpoint,
+			expectedArmAudience:  cloud.AzurePublic.Services[cloud.ResourceManager].Audience,
+			expectedServiceCount: len(cloud.AzurePublic.Services),
+		},
+		{
+			name:                 "resource manager and authentication endpoints",
+			config:               `{"name": "AzureStackCloud", "resourceManagerEndpoint": "https://management.example.com/", "activeDirectoryEndpoint": "https://login.example.com/"}`,
+			expectedAuthority:    "https://login.example.com/",
+			expectedArmEndpoint:  "https://management.example.com/",
+			expectedArmAudience:  "https://management.example.com/",
+			expectedServiceCount: len(cloud.AzurePublic.Services),
+		},
+		{
+			name:                 "token audience",
+			config:               `{"name": "AzureStackCloud", "resourceManagerEndpoint": "https://management.example.com/", "tokenAudience": "https://audience.example.com/"}`,
+			expectedAuthority:    cloud.AzurePublic.ActiveDirectoryAuthorityHost,
+			expectedArmEndpoint:  "https://management.example.com/",
+			expectedArmAudience:  "https://audience.example.com/",
+			expectedServiceCount: len(cloud.AzurePublic.Services),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cloud.json")
+			if err := os.WriteFile(path, []byte(test.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			e := &AzureTemplateExecutor{}
+			e.opts.Cloud.ConfigFile = path
+
+			armEndpoint := cloud.AzurePublic.Services[cloud.ResourceManager].Endpoint
+
+			result, err := e.cloudConfiguration(cloud.AzurePublic)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.ActiveDirectoryAuthorityHost != test.expectedAuthority {
+				t.Errorf("expected authority %q, got %q", test.expectedAuthority, result.ActiveDirectoryAuthorityHost)
+			}
+
+			if val := result.Services[cloud.ResourceManager].Endpoint; val != test.expectedArmEndpoint {
+				t.Errorf("expected endpoint %q, got %q", test.expectedArmEndpoint, val)
+			}
+
+			if val := result.Services[cloud.ResourceManager].Audience; val != test.expectedArmAudience {
+				t.Errorf("expected audience %q, got %q", test.expectedArmAudience, val)
+			}
+
+			if len(result.Services) != test.expectedServiceCount {
+				t.Errorf("expected %v services, got %v", test.expectedServiceCount, len(result.Services))
+			}
+
+			if cloud.AzurePublic.Services[cloud.ResourceManager].Endpoint != armEndpoint {
+				t.Errorf("configuration of the built-in cloud was changed")
+			}
+		})
+	}
+}
@@ -6,17 +6,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
-	"os"
-	"path/filepath"
 	"strings"
+	"sync"
 	textTemplate "text/template"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Masterminds/sprig/v3"
-	cache "github.com/patrickmn/go-cache"
+	msgraphsdk "github.com/microsoftgraph/msgraph-sdk-go"
 	"github.com/webdevops/go-common/azuresdk/armclient"
+	"github.com/webdevops/go-common/azuresdk/cloudconfig"
 	"github.com/webdevops/go-common/log/slogger"
 	"github.com/webdevops/go-common/msgraphsdk/msgraphclient"
 
@@ -28,8 +31,22 @@ type (
 		ctx    context.Context
 		logger *slogger.Logger
 
-		cache    *cache.Cache
-		cacheTtl time.Duration
+		cache           *resultCache
+		cacheTtl        time.Duration
+		persistentCache *PersistentCache
+
+		// lockFile and summary are collecting the used entries and summary rows of the templates
+		lockFile *lockStore
+		summary  *summaryStore
+
+		// freshFetch forces fetching results instead of using cached results (see azFresh)
+		freshFetch bool
+
+		// prefetchOnly only fetches results into the cache (see prefetch),
+		// lock file, summary and policy are recorded when the template is executed
+		prefetchOnly bool
+
+		providers Providers
 
 		opts models.Opts
 
@@ -40,15 +57,22 @@ type (
 
 		currentPath string
 
+		fsys fs.FS
+
 		LintMode bool
+		lint     *lintState
 
 		azureCliAccountInfo map[string]interface{}
+
+		// authProfile is the credential profile selected by azWithProfile (nil for --auth.method)
+		authProfile *AuthProfile
 	}
 )
 
 var (
 	azureClient   *armclient.ArmClient
 	msGraphClient *msgraphclient.MsGraphClient
+	clientLock    sync.Mutex
 )
 
 func New(ctx context.Context, opts models.Opts, logger *slogger.Logger) *AzureTemplateExecutor {
@@ -58,6 +82,7 @@ func New(ctx context.Context, opts models.Opts, logger *slogger.Logger) *AzureTe
 		opts:   opts,
 
 		cacheTtl: 15 * time.Minute,
+		lint:     &lintState{},
 	}
 	e.init()
 	return e
@@ -65,67 +90,180 @@ func New(ctx context.Context, opts models.Opts, logger *slogger.Logger) *AzureTe
 
 func (e *AzureTemplateExecutor) init() {
 	e.cache = globalCache
+	e.lockFile = lockFile
+	e.summary = summary
+	e.providers = newAzureSdkProviders(e)
+
+	if e.opts.Cache.Ttl > 0 {
+		e.cacheTtl = e.opts.Cache.Ttl
+	}
+
+	if e.opts.Cache.Dir != "" && !e.opts.Cache.Disabled {
+		persistentCache, err := NewPersistentCache(e.opts.Cache.Dir, e.opts.Cache.EncryptionKey)
+		if err != nil {
+			e.logger.Warn(`unable to use persistent cache, continuing without`, slog.Any("error", err))
+		} else {
+			e.persistentCache = persistentCache
+		}
+	}
+}
+
+// SetProviders replaces the backends used by the template functions (eg. for tests or embedding),
+// unset providers are keeping their current implementation.
+// Executors with custom providers are using their own cache and no persistent cache,
+// so results of different backends are not mixed up.
+func (e *AzureTemplateExecutor) SetProviders(providers Providers) {
+	if !providers.isEmpty() {
+		e.cache = newResultCache()
+		e.persistentCache = nil
+	}
+
+	if providers.KeyVault != nil {
+		e.providers.KeyVault = providers.KeyVault
+	}
+
+	if providers.AppConfig != nil {
+		e.providers.AppConfig = providers.AppConfig
+	}
+
+	if providers.Resource != nil {
+		e.providers.Resource = providers.Resource
+	}
+
+	if providers.ResourceGraph != nil {
+		e.providers.ResourceGraph = providers.ResourceGraph
+	}
+
+	if providers.MsGraph != nil {
+		e.providers.MsGraph = providers.MsGraph
+	}
+
+	if providers.Storage != nil {
+		e.providers.Storage = providers.Storage
+	}
 }
 
-func (e *AzureTemplateExecutor) azureClient() *armclient.ArmClient {
-	var err error
+func (e *AzureTemplateExecutor) azureClient() (*armclient.ArmClient, error) {
+	clientLock.Lock()
+	defer clientLock.Unlock()
+
+	return e.initAzureClient()
+}
+
+// initAzureClient initializes the shared Azure client, clientLock must be held by the caller
+func (e *AzureTemplateExecutor) initAzureClient() (*armclient.ArmClient, error) {
 	if azureClient == nil {
-		azureClient, err = armclient.NewArmClientFromEnvironment(e.logger.Slog())
+		client, err := armclient.NewArmClientFromEnvironment(e.logger.Slog())
 		if err != nil {
-			e.logger.Error(err.Error())
-			os.Exit(1)
+			return nil, fmt.Errorf(`unable to create Azure client: %w`, err)
 		}
 
-		azureClient.SetUserAgent(e.UserAgent)
-		azureClient.UseAzCliAuth()
-		if err := azureClient.LazyConnect(); err != nil {
-			e.logger.Error(err.Error())
-			os.Exit(1)
+		client.SetUserAgent(e.UserAgent)
+		if e.authMethod() == AuthMethodAzCli {
+			client.UseAzCliAuth()
+		}
+		if err := client.LazyConnect(); err != nil {
+			return nil, fmt.Errorf(`unable to connect to Azure: %w`, err)
 		}
+
+		azureClient = client
+	}
+	return azureClient, nil
+}
+
+// armClientOptions returns the client options for Azure Resource Manager clients,
+// endpoints of the cloud config (see --cloud.config) are applied
+func (e *AzureTemplateExecutor) armClientOptions() (*arm.ClientOptions, error) {
+	client, err := e.azureClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := arm.ClientOptions{}
+	if val := client.NewArmClientOptions(); val != nil {
+		ret = *val
+	}
+
+	ret.Cloud, err = e.cloudConfiguration(ret.Cloud)
+	if err != nil {
+		return nil, err
 	}
-	return azureClient
+	return &ret, nil
 }
 
-func (e *AzureTemplateExecutor) msGraphClient() *msgraphclient.MsGraphClient {
-	var err error
+// azCoreClientOptions returns the client options for Azure SDK data plane clients,
+// endpoints of the cloud config (see --cloud.config) are applied
+func (e *AzureTemplateExecutor) azCoreClientOptions() (*azcore.ClientOptions, error) {
+	client, err := e.azureClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := azcore.ClientOptions{}
+	if val := client.NewAzCoreClientOptions(); val != nil {
+		ret = *val
+	}
+
+	ret.Cloud, err = e.cloudConfiguration(ret.Cloud)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (e *AzureTemplateExecutor) msGraphClient() (*msgraphsdk.GraphServiceClient, error) {
+	if e.authProfile != nil || e.authMethod() != AuthMethodAzCli {
+		return e.msGraphCredentialClient()
+	}
+
+	clientLock.Lock()
+	defer clientLock.Unlock()
+
 	if msGraphClient == nil {
 		// ensure azureclient init
-		if azureClient == nil {
-			e.azureClient()
+		if _, err := e.initAzureClient(); err != nil {
+			return nil, err
 		}
 
-		msGraphClient, err = msgraphclient.NewMsGraphClientFromEnvironment(e.logger.Slog())
+		client, err := msgraphclient.NewMsGraphClientFromEnvironment(e.logger.Slog())
 		if err != nil {
-			e.logger.Error(err.Error())
-			os.Exit(1)
+			return nil, fmt.Errorf(`unable to create MsGraph client: %w`, err)
 		}
 
-		msGraphClient.SetUserAgent(e.UserAgent)
-		msGraphClient.UseAzCliAuth()
+		client.SetUserAgent(e.UserAgent)
+		client.UseAzCliAuth()
+
+		msGraphClient = client
 	}
-	return msGraphClient
+	return msGraphClient.ServiceClient(), nil
 }
 
 func (e *AzureTemplateExecutor) SetUserAgent(val string) {
 	e.UserAgent = val
 }
 
-func (e *AzureTemplateExecutor) SetTemplateRootPath(val string) {
-	path, err := filepath.Abs(filepath.Clean(val))
+func (e *AzureTemplateExecutor) SetTemplateRootPath(val string) error {
+	path, err := e.fileCleanPath(val)
 	if err != nil {
-		e.logger.Error(`invalid base path`, slog.String("path", val), slog.Any("error", err))
-		os.Exit(1)
+		return fmt.Errorf(`invalid base path "%v": %w`, val, err)
 	}
 	e.TemplateRootPath = path
+	return nil
 }
 
-func (e *AzureTemplateExecutor) SetTemplateRelPath(val string) {
-	path, err := filepath.Abs(filepath.Clean(val))
+func (e *AzureTemplateExecutor) SetTemplateRelPath(val string) error {
+	path, err := e.fileCleanPath(val)
 	if err != nil {
-		e.logger.Error(`invalid base path`, slog.String("path", val), slog.Any("error", err))
-		os.Exit(1)
+		return fmt.Errorf(`invalid relative path "%v": %w`, val, err)
 	}
 	e.TemplateRelPath = path
+	return nil
+}
+
+// SetFS sets the filesystem used for reading templates and files (filesGet, filesGlob, include),
+// if not set the local filesystem is used
+func (e *AzureTemplateExecutor) SetFS(fsys fs.FS) {
+	e.fsys = fsys
 }
 
 func (e *AzureTemplateExecutor) SetLintMode(val bool) {
@@ -134,6 +272,7 @@ func (e *AzureTemplateExecutor) SetLintMode(val bool) {
 
 func (e *AzureTemplateExecutor) TxtFuncMap(tmpl *textTemplate.Template) textTemplate.FuncMap {
 	includedNames := make(map[string]int)
+	includedNamesLock := sync.Mutex{}
 
 	funcMap := map[string]interface{}{
 		// azure
@@ -150,9 +289,14 @@ func (e *AzureTemplateExecutor) TxtFuncMap(tmpl *textTemplate.Template) textTemp
 		`azAccountInfo`:                         e.azAccountInfo,
 
 		// azure keyvault
-		`azKeyVaultSecret`:         e.azKeyVaultSecret,
-		`azKeyVaultSecretVersions`: e.azKeyVaultSecretVersions,
-		`azKeyVaultSecretList`:     e.azKeyVaultSecretList,
+		`azKeyVaultSecret`:             e.azKeyVaultSecret,
+		`azKeyVaultSecretVersions`:     e.azKeyVaultSecretVersions,
+		`azKeyVaultSecretList`:         e.azKeyVaultSecretList,
+		`azKeyVaultSecretValues`:       e.azKeyVaultSecretValues,
+		`azKeyVaultCertificate`:        e.azKeyVaultCertificate,
+		`azKeyVaultCertificateWithKey`: e.azKeyVaultCertificateWithKey,
+		`azKeyVaultCertificateList`:    e.azKeyVaultCertificateList,
+		`azKeyVaultKey`:                e.azKeyVaultKey,
 
 		// azure redis
 		`azRedisAccessKeys`: e.azRedisAccessKeys,
@@ -165,7 +309,12 @@ func (e *AzureTemplateExecutor) TxtFuncMap(tmpl *textTemplate.Template) textTemp
 		`azEventHubListByNamespace`: e.azEventHubListByNamespace,
 
 		// azure app config
-		`azAppConfigSetting`: e.azAppConfigSetting,
+		`azAppConfigSetting`:             e.azAppConfigSetting,
+		`azAppConfigSettingList`:         e.azAppConfigSettingList,
+		`azAppConfigFeatureFlag`:         e.azAppConfigFeatureFlag,
+		`azAppConfigFeatureFlagList`:     e.azAppConfigFeatureFlagList,
+		`azAppConfigSnapshotSetting`:     e.azAppConfigSnapshotSetting,
+		`azAppConfigSnapshotSettingList`: e.azAppConfigSnapshotSettingList,
 
 		// azure managedCluster
 		`azManagedClusterUserCredentials`: e.azManagedClusterUserCredentials,
@@ -187,9 +336,25 @@ func (e *AzureTemplateExecutor) TxtFuncMap(tmpl *textTemplate.Template) textTemp
 		`mgApplicationByDisplayName`:      e.mgApplicationByDisplayName,
 		`mgApplicationList`:               e.mgApplicationList,
 
+		// cache
+		`azFresh`: func(funcName string, args ...interface{}) (interface{}, error) {
+			return e.azFresh(tmpl, funcName, args...)
+		},
+
+		// auth
+		`azWithProfile`: func(profileName string, funcName string, args ...interface{}) (interface{}, error) {
+			return e.azWithProfile(tmpl, profileName, funcName, args...)
+		},
+
 		// misc
 		`jsonPath`: e.jsonPath,
 
+		// certificates
+		`certificateFromSecret`: e.certificateFromSecret,
+		`certificateFromPkcs12`: e.certificateFromPkcs12,
+		`certificateFromPem`:    e.certificateFromPem,
+		`kubernetesTlsSecret`:   e.kubernetesTlsSecret,
+
 		// time
 		`fromUnixtime`: fromUnixtime,
 		`toRFC3339`:    toRFC3339,
@@ -214,32 +379,45 @@ func (e *AzureTemplateExecutor) TxtFuncMap(tmpl *textTemplate.Template) textTemp
 		"include": func(path string, data interface{}) (string, error) {
 			sourcePath := e.fileMakePathAbs(path)
 
+			includedNamesLock.Lock()
 			if v, ok := includedNames[sourcePath]; ok {
 				if v > recursionMaxNums {
+					includedNamesLock.Unlock()
 					return "", fmt.Errorf(`too many recursions for inclusion of '%v'`, path)
 				}
 				includedNames[sourcePath]++
 			} else {
 				includedNames[sourcePath] = 1
 			}
+			includedNamesLock.Unlock()
 
-			content, err := os.ReadFile(sourcePath)
+			defer func() {
+				includedNamesLock.Lock()
+				includedNames[sourcePath]--
+				includedNamesLock.Unlock()
+			}()
+
+			content, err := e.readFile(sourcePath)
 			if err != nil {
 				return "", fmt.Errorf(`unable to read file: %w`, err)
 			}
 
-			parsedContent, err := tmpl.Parse(string(content))
+			parsedContent, err := e.parseTemplate(tmpl, sourcePath, string(content))
 			if err != nil {
-				return "", fmt.Errorf(`unable to parse file: %w`, err)
+				if e.LintMode {
+					// continue linting of the including template
+					e.addLintFinding(LintRuleParseError, e.newTemplateError(sourcePath, err))
+					return "", nil
+				}
+				return "", e.newTemplateError(sourcePath, err)
 			}
 
 			var buf bytes.Buffer
 			err = parsedContent.Execute(&buf, data)
 			if err != nil {
-				return "", fmt.Errorf("unable to process template:\n%w", err)
+				return "", e.newTemplateError(sourcePath, err)
 			}
 
-			includedNames[sourcePath]--
 			return buf.String(), nil
 		},
 
@@ -247,16 +425,14 @@ func (e *AzureTemplateExecutor) TxtFuncMap(tmpl *textTemplate.Template) textTemp
 			if val == nil {
 				if e.LintMode {
 					// Don't fail on missing required values when linting
-					e.logger.Info(fmt.Sprintf("[TPL::required] missing required value: %s", message))
-					return "", nil
+					return "", e.lintFinding(LintRuleRequired, fmt.Sprintf("missing required value: %s", message))
 				}
 				return val, errors.New(message)
 			} else if _, ok := val.(string); ok {
 				if val == "" {
 					if e.LintMode {
 						// Don't fail on missing required values when linting
-						e.logger.Info(fmt.Sprintf("[TPL::required] missing required value: %s", message))
-						return "", nil
+						return "", e.lintFinding(LintRuleRequired, fmt.Sprintf("missing required value: %s", message))
 					}
 					return val, errors.New(message)
 				}
@@ -267,8 +443,7 @@ func (e *AzureTemplateExecutor) TxtFuncMap(tmpl *textTemplate.Template) textTemp
 		"fail": func(message string) (string, error) {
 			if e.LintMode {
 				// Don't fail when linting
-				e.logger.Info(fmt.Sprintf("[TPL::fail] fail: %s", message))
-				return "", nil
+				return "", e.lintFinding(LintRuleFail, message)
 			}
 			return "", errors.New(message)
 		},
@@ -307,35 +482,56 @@ func (e *AzureTemplateExecutor) TxtTemplate(name string) *textTemplate.Template
 	return tmpl
 }
 
-func (e *AzureTemplateExecutor) Parse(path string, templateData interface{}, buf *strings.Builder) error {
+// Parse reads template file from path and writes the processed template to w
+func (e *AzureTemplateExecutor) Parse(path string, templateData interface{}, w io.Writer) error {
 	e.currentPath = path
 
-	tmpl := e.TxtTemplate(path)
-
-	content, err := os.ReadFile(path)
+	content, err := e.readFile(path)
 	if err != nil {
 		return e.handleCicdError(fmt.Errorf(`unable to read file: '%w'`, err))
 	}
 
-	parsedContent, err := tmpl.Parse(string(content))
+	return e.execute(path, string(content), templateData, w)
+}
+
+// ParseReader reads template from r and writes the processed template to w
+func (e *AzureTemplateExecutor) ParseReader(name string, r io.Reader, templateData interface{}, w io.Writer) error {
+	e.currentPath = name
+
+	content, err := io.ReadAll(r)
 	if err != nil {
-		return e.handleCicdError(fmt.Errorf(`unable to parse file: %w`, err))
+		return e.handleCicdError(fmt.Errorf(`unable to read template: '%w'`, err))
 	}
 
-	oldPwd, err := os.Getwd()
+	return e.execute(name, string(content), templateData, w)
+}
+
+func (e *AzureTemplateExecutor) execute(name, content string, templateData interface{}, w io.Writer) error {
+	tmpl := e.TxtTemplate(name)
+
+	parsedContent, err := e.parseTemplate(tmpl, name, content)
 	if err != nil {
-		return e.handleCicdError(err)
+		if e.LintMode {
+			e.addLintFinding(LintRuleParseError, e.newTemplateError(name, err))
+			return nil
+		}
+		return e.handleCicdError(fmt.Errorf(`unable to parse file: %w`, e.newTemplateError(name, err)))
 	}
 
-	if err = os.Chdir(e.TemplateRootPath); err != nil {
-		return e.handleCicdError(err)
+	if e.LintMode {
+		// findings are collected, see LintFindings
+		e.lintExecute(name, parsedContent, templateData, w)
+		return nil
 	}
 
-	if err = parsedContent.Execute(buf, templateData); err != nil {
-		return fmt.Errorf(`unable to process template: '%w'`, err)
+	e.lockFile.startTemplate(e.lockTemplateName())
+	e.prefetch(parsedContent)
+
+	if err = parsedContent.Execute(w, templateData); err != nil {
+		return e.handleCicdError(fmt.Errorf(`unable to process template: %w`, e.newTemplateError(name, err)))
 	}
 
-	if err = os.Chdir(oldPwd); err != nil {
+	if err := e.keyVaultPolicyError(); err != nil {
 		return e.handleCicdError(err)
 	}
 
@@ -352,25 +548,173 @@ func (e *AzureTemplateExecutor) lintResult() (interface{}, bool) {
 
 // cacheResult caches template function results (eg. Azure REST API resource information)
 func (e *AzureTemplateExecutor) cacheResult(cacheKey string, callback func() (interface{}, error)) (interface{}, error) {
-	if val, ok := e.cache.Get(cacheKey); ok {
+	if e.authProfile != nil {
+		// results of auth profiles are cached separately
+		cacheKey = generateCacheKey(cacheKey, "@"+e.authProfile.name)
+	}
+
+	isSecret := secretFunctions[cacheKeyFunctionName(cacheKey)]
+
+	if fixtures.isReplay() {
+		e.logger.Info("using recorded fixture", slog.String("cacheKey", cacheKey))
+		val, err := fixtures.get(cacheKey)
+		if err == nil && isSecret {
+			registerSecretResult(val)
+		}
+		return val, err
+	}
+
+	useCache := !e.opts.Cache.Disabled && !e.freshFetch
+
+	if val, ok := e.cache.Get(cacheKey); ok && useCache {
 		e.logger.Info("found in cache", slog.String("cacheKey", cacheKey))
 		return val, nil
 	}
 
-	ret, err := callback()
+	if e.persistentCache != nil && useCache {
+		if val, ok := e.persistentCache.Get(cacheKey); ok {
+			e.logger.Info("found in persistent cache", slog.String("cacheKey", cacheKey))
+			if isSecret {
+				// secret values are masked when fetched, so they also need to be masked when using the persistent cache
+				e.handleCicdMaskSecretResult(val)
+				registerSecretResult(val)
+			}
+
+			e.cache.Set(cacheKey, val, e.cacheTtlForKey(cacheKey))
+			return val, e.recordFixture(cacheKey, val)
+		}
+	}
+
+	// wait for identical call which is already in-flight (eg. from other template file)
+	call, inFlight := e.cache.startInFlightCall(cacheKey)
+	if inFlight {
+		e.logger.Info("waiting for in-flight call", slog.String("cacheKey", cacheKey))
+		return call.wait()
+	}
+
+	// call might be finished in the meantime
+	if val, ok := e.cache.Get(cacheKey); ok && useCache {
+		call.finish(cacheKey, val, nil)
+		return val, nil
+	}
+
+	ret, err := call.run(cacheKey, func() (interface{}, error) {
+		ret, err := callback()
+		if err != nil {
+			return nil, err
+		}
+
+		if isSecret {
+			if val, err := transformToInterface(ret); err == nil {
+				registerSecretResult(val)
+			}
+		}
+
+		if !e.opts.Cache.Disabled {
+			e.cache.Set(cacheKey, ret, e.cacheTtlForKey(cacheKey))
+		}
+		return ret, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	e.cache.SetDefault(cacheKey, ret)
+	if e.persistentCache != nil {
+		if err := e.persistentCache.Set(cacheKey, ret, e.cacheTtlForKey(cacheKey)); err != nil {
+			e.logger.Warn(`unable to store result in persistent cache`, slog.String("cacheKey", cacheKey), slog.Any("error", err))
+		}
+	}
+
+	if err := e.recordFixture(cacheKey, ret); err != nil {
+		return nil, err
+	}
 
 	return ret, nil
 }
 
+// azFresh calls an Azure template function without using cached results, the fresh result is stored in the cache
+func (e *AzureTemplateExecutor) azFresh(tmpl *textTemplate.Template, funcName string, args ...interface{}) (interface{}, error) {
+	canonicalName := canonicalTemplateFunctionName(funcName)
+	if canonicalName == `azFresh` || (!strings.HasPrefix(canonicalName, "az") && !strings.HasPrefix(canonicalName, "mg")) {
+		return nil, fmt.Errorf(`{{azFresh}} only supports Azure and MsGraph template functions, got "%v"`, funcName)
+	}
+
+	fresh := *e
+	fresh.freshFetch = true
+
+	fn, ok := fresh.TxtFuncMap(tmpl)[funcName]
+	if !ok {
+		return nil, fmt.Errorf(`{{azFresh}} unknown template function "%v"`, funcName)
+	}
+
+	return callTemplateFunction(funcName, fn, args)
+}
+
+// azWithProfile calls an Azure template function using the credential of a named auth profile (see --auth.profiles)
+func (e *AzureTemplateExecutor) azWithProfile(tmpl *textTemplate.Template, profileName string, funcName string, args ...interface{}) (interface{}, error) {
+	canonicalName := canonicalTemplateFunctionName(funcName)
+	if canonicalName == `azWithProfile` || (!strings.HasPrefix(canonicalName, "az") && !strings.HasPrefix(canonicalName, "mg")) {
+		return nil, fmt.Errorf(`{{azWithProfile}} only supports Azure and MsGraph template functions, got "%v"`, funcName)
+	}
+
+	profile, err := e.loadAuthProfile(profileName)
+	if err != nil {
+		return nil, fmt.Errorf(`{{azWithProfile}} %w`, err)
+	}
+
+	withProfile := *e
+	withProfile.authProfile = profile
+	withProfile.azureCliAccountInfo = nil
+	withProfile.providers = replaceAzureSdkProviders(e.providers, newAzureSdkProviders(&withProfile))
+
+	fn, ok := withProfile.TxtFuncMap(tmpl)[funcName]
+	if !ok {
+		return nil, fmt.Errorf(`{{azWithProfile}} unknown template function "%v"`, funcName)
+	}
+
+	return callTemplateFunction(funcName, fn, args)
+}
+
+// recordFixture records result if fixture recording is enabled
+func (e *AzureTemplateExecutor) recordFixture(cacheKey string, val interface{}) error {
+	if e.opts.Fixture.Record != "" {
+		return fixtures.record(cacheKey, val, e.opts.Fixture.RedactSecrets)
+	}
+	return nil
+}
+
+// cacheTtlForKey returns the TTL for cacheKey (per template function if configured)
+func (e *AzureTemplateExecutor) cacheTtlForKey(cacheKey string) time.Duration {
+	if ttl, ok := e.opts.Cache.FunctionTtl[cacheKeyFunctionName(cacheKey)]; ok {
+		return ttl
+	}
+
+	return e.cacheTtl
+}
+
+// cloudName returns the name of the current Azure cloud (recorded cloud name in replay mode)
+func (e *AzureTemplateExecutor) cloudName() (cloudconfig.CloudName, error) {
+	if fixtures.isReplay() {
+		return fixtures.cloudName(), nil
+	}
+
+	client, err := e.azureClient()
+	if err != nil {
+		return "", err
+	}
+
+	cloudName := client.GetCloudName()
+	if e.opts.Fixture.Record != "" {
+		fixtures.setCloudName(cloudName)
+	}
+
+	return cloudName, nil
+}
+
 // fetchAzureResource fetches json representation of Azure resource by resourceID and apiVersion
 func (e *AzureTemplateExecutor) fetchAzureResource(resourceID string, apiVersion string) (interface{}, error) {
-	resourceInfo, err := armclient.ParseResourceId(resourceID)
-	if err != nil {
+	// validate resourceID (also in lint mode)
+	if _, err := armclient.ParseResourceId(resourceID); err != nil {
 		return nil, fmt.Errorf(`unable to parse Azure resourceID '%v': %w`, resourceID, err)
 	}
 
@@ -378,12 +722,7 @@ func (e *AzureTemplateExecutor) fetchAzureResource(resourceID string, apiVersion
 		return val, nil
 	}
 
-	client, err := armresources.NewClient(resourceInfo.Subscription, e.azureClient().GetCred(), e.azureClient().NewArmClientOptions())
-	if err != nil {
-		return nil, err
-	}
-
-	resource, err := client.GetByID(e.ctx, resourceID, apiVersion, nil)
+	resource, err := e.providers.Resource.GetResourceByID(e.ctx, resourceID, apiVersion)
 	if err != nil {
 		return nil, fmt.Errorf(`unable to fetch Azure resource '%v': %w`, resourceID, err)
 	}
@@ -2,4 +2,6 @@ package azuretpl
 
 const (
 	recursionMaxNums = 100
+
+	AppConfigContentTypeKeyVaultRef = "application/vnd.microsoft.appconfig.keyvaultref+json"
 )
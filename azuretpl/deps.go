@@ -0,0 +1,281 @@
+package azuretpl
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	textTemplate "text/template"
+)
+
+const (
+	PermissionPlaneControl = "control"
+	PermissionPlaneData    = "data"
+	PermissionPlaneGraph   = "graph"
+
+	// PermissionResourceTypeRead is replaced by the read action of the resource type (eg. Microsoft.Network/virtualNetworks/read),
+	// the placeholder is kept if the resource type is unknown (eg. dynamic resource)
+	PermissionResourceTypeRead = "{resourceType}/read"
+)
+
+type (
+	// FunctionPermission is an Azure permission which is required by a template function
+	FunctionPermission struct {
+		Plane  string `json:"plane"`
+		Action string `json:"action"`
+	}
+
+	// TemplateDependency is a call of an Azure template function found by static analysis of a template
+	TemplateDependency struct {
+		Function string           `json:"function"`
+		Location TemplateLocation `json:"location"`
+
+		// Arguments are the constant arguments of the call, dynamic arguments are null
+		Arguments []interface{} `json:"arguments"`
+
+		// DynamicArguments are the indexes of arguments which are only known when processing the template
+		DynamicArguments []int `json:"dynamicArguments,omitempty"`
+
+		// Resource is the Azure resource, vault url or scope which is accessed (empty if dynamic or not used)
+		Resource string `json:"resource,omitempty"`
+
+		// Profile is the auth profile used by azWithProfile (empty for the default credential)
+		Profile string `json:"profile,omitempty"`
+
+		Permissions []FunctionPermission `json:"permissions"`
+
+		// DynamicPermissions are required on resources which are only known when processing the template
+		// (eg. KeyVault secrets referenced by AppConfig settings)
+		DynamicPermissions []FunctionPermission `json:"dynamicPermissions,omitempty"`
+	}
+
+	functionDependency struct {
+		// resourceArgument is the index of the argument containing the resource or scope (-1 if none)
+		resourceArgument int
+
+		// resourcePrefix is prepended to the resource argument (eg. for management group ids)
+		resourcePrefix string
+
+		permissions []FunctionPermission
+
+		// dynamicPermissions are required on resources which are only known when processing the template
+		dynamicPermissions []FunctionPermission
+	}
+)
+
+var (
+	// appConfigKeyVaultReferencePermissions are required to resolve KeyVault references of AppConfig settings,
+	// the referenced vaults are only known when processing the template
+	appConfigKeyVaultReferencePermissions = dataPermissions("Microsoft.KeyVault/vaults/secrets/getSecret/action")
+
+	// functionDependencies are the permissions required by the Azure template functions
+	functionDependencies = map[string]functionDependency{
+		`azAccountInfo`:                         {resourceArgument: -1},
+		`azResource`:                            {resourceArgument: 0, permissions: controlPermissions(PermissionResourceTypeRead)},
+		`azResourceList`:                        {resourceArgument: 0, permissions: controlPermissions("Microsoft.Resources/subscriptions/resources/read", "Microsoft.Resources/subscriptions/resourceGroups/resources/read")},
+		`azManagementGroup`:                     {resourceArgument: 0, resourcePrefix: "/providers/Microsoft.Management/managementGroups/", permissions: controlPermissions("Microsoft.Management/managementGroups/read")},
+		`azManagementGroupSubscriptionList`:     {resourceArgument: 0, resourcePrefix: "/providers/Microsoft.Management/managementGroups/", permissions: controlPermissions("Microsoft.Management/managementGroups/descendants/read")},
+		`azSubscription`:                        {resourceArgument: 0, resourcePrefix: "/subscriptions/", permissions: controlPermissions("Microsoft.Resources/subscriptions/read")},
+		`azSubscriptionList`:                    {resourceArgument: -1, permissions: controlPermissions("Microsoft.Resources/subscriptions/read")},
+		`azPublicIpAddress`:                     {resourceArgument: 0, permissions: controlPermissions("Microsoft.Network/publicIPAddresses/read")},
+		`azPublicIpPrefixAddressPrefix`:         {resourceArgument: 0, permissions: controlPermissions("Microsoft.Network/publicIPPrefixes/read")},
+		`azVirtualNetworkAddressPrefixes`:       {resourceArgument: 0, permissions: controlPermissions("Microsoft.Network/virtualNetworks/read")},
+		`azVirtualNetworkSubnetAddressPrefixes`: {resourceArgument: 0, permissions: controlPermissions("Microsoft.Network/virtualNetworks/subnets/read")},
+		`azKeyVaultSecret`:                      {resourceArgument: 0, permissions: dataPermissions("Microsoft.KeyVault/vaults/secrets/getSecret/action")},
+		`azKeyVaultSecretVersions`:              {resourceArgument: 0, permissions: dataPermissions("Microsoft.KeyVault/vaults/secrets/readMetadata/action", "Microsoft.KeyVault/vaults/secrets/getSecret/action")},
+		`azKeyVaultSecretList`:                  {resourceArgument: 0, permissions: dataPermissions("Microsoft.KeyVault/vaults/secrets/readMetadata/action")},
+		`azKeyVaultSecretValues`:                {resourceArgument: 0, permissions: dataPermissions("Microsoft.KeyVault/vaults/secrets/readMetadata/action", "Microsoft.KeyVault/vaults/secrets/getSecret/action")},
+		`azKeyVaultCertificate`:                 {resourceArgument: 0, permissions: dataPermissions("Microsoft.KeyVault/vaults/certificates/read")},
+		`azKeyVaultCertificateWithKey`:          {resourceArgument: 0, permissions: dataPermissions("Microsoft.KeyVault/vaults/certificates/read", "Microsoft.KeyVault/vaults/secrets/getSecret/action")},
+		`azKeyVaultCertificateList`:             {resourceArgument: 0, permissions: dataPermissions("Microsoft.KeyVault/vaults/certificates/read")},
+		`azKeyVaultKey`:                         {resourceArgument: 0, permissions: dataPermissions("Microsoft.KeyVault/vaults/keys/read")},
+		`azRedisAccessKeys`:                     {resourceArgument: 0, permissions: controlPermissions("Microsoft.Cache/redis/listKeys/action")},
+		`azStorageAccountAccessKeys`:            {resourceArgument: 0, permissions: controlPermissions("Microsoft.Storage/storageAccounts/listKeys/action")},
+		`azStorageAccountContainerBlob`:         {resourceArgument: 0, permissions: dataPermissions("Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read")},
+		`azEventHubListByNamespace`:             {resourceArgument: 0, permissions: controlPermissions("Microsoft.EventHub/namespaces/eventhubs/read")},
+		`azAppConfigSetting`:                    {resourceArgument: 0, permissions: dataPermissions("Microsoft.AppConfiguration/configurationStores/keyValues/read"), dynamicPermissions: appConfigKeyVaultReferencePermissions},
+		`azAppConfigSettingList`:                {resourceArgument: 0, permissions: dataPermissions("Microsoft.AppConfiguration/configurationStores/keyValues/read"), dynamicPermissions: appConfigKeyVaultReferencePermissions},
+		`azAppConfigFeatureFlag`:                {resourceArgument: 0, permissions: dataPermissions("Microsoft.AppConfiguration/configurationStores/keyValues/read")},
+		`azAppConfigFeatureFlagList`:            {resourceArgument: 0, permissions: dataPermissions("Microsoft.AppConfiguration/configurationStores/keyValues/read")},
+		`azAppConfigSnapshotSetting`:            {resourceArgument: 0, permissions: dataPermissions("Microsoft.AppConfiguration/configurationStores/snapshots/read", "Microsoft.AppConfiguration/configurationStores/keyValues/read"), dynamicPermissions: appConfigKeyVaultReferencePermissions},
+		`azAppConfigSnapshotSettingList`:        {resourceArgument: 0, permissions: dataPermissions("Microsoft.AppConfiguration/configurationStores/snapshots/read", "Microsoft.AppConfiguration/configurationStores/keyValues/read"), dynamicPermissions: appConfigKeyVaultReferencePermissions},
+		`azManagedClusterUserCredentials`:       {resourceArgument: 0, permissions: controlPermissions("Microsoft.ContainerService/managedClusters/listClusterUserCredential/action")},
+		`azResourceGraphQuery`:                  {resourceArgument: 0, permissions: controlPermissions("Microsoft.ResourceGraph/resources/read")},
+		`azRoleDefinition`:                      {resourceArgument: 0, permissions: controlPermissions("Microsoft.Authorization/roleDefinitions/read")},
+		`azRoleDefinitionList`:                  {resourceArgument: 0, permissions: controlPermissions("Microsoft.Authorization/roleDefinitions/read")},
+		`mgUserByUserPrincipalName`:             {resourceArgument: -1, permissions: graphPermissions("User.Read.All")},
+		`mgUserList`:                            {resourceArgument: -1, permissions: graphPermissions("User.Read.All")},
+		`mgGroupByDisplayName`:                  {resourceArgument: -1, permissions: graphPermissions("Group.Read.All")},
+		`mgGroupList`:                           {resourceArgument: -1, permissions: graphPermissions("Group.Read.All")},
+		`mgServicePrincipalByDisplayName`:       {resourceArgument: -1, permissions: graphPermissions("Application.Read.All")},
+		`mgServicePrincipalList`:                {resourceArgument: -1, permissions: graphPermissions("Application.Read.All")},
+		`mgApplicationByDisplayName`:            {resourceArgument: -1, permissions: graphPermissions("Application.Read.All")},
+		`mgApplicationList`:                     {resourceArgument: -1, permissions: graphPermissions("Application.Read.All")},
+	}
+)
+
+func controlPermissions(actions ...string) []FunctionPermission {
+	return newFunctionPermissions(PermissionPlaneControl, actions)
+}
+
+func dataPermissions(actions ...string) []FunctionPermission {
+	return newFunctionPermissions(PermissionPlaneData, actions)
+}
+
+func graphPermissions(permissions ...string) []FunctionPermission {
+	return newFunctionPermissions(PermissionPlaneGraph, permissions)
+}
+
+func newFunctionPermissions(plane string, actions []string) []FunctionPermission {
+	ret := make([]FunctionPermission, len(actions))
+	for i, action := range actions {
+		ret[i] = FunctionPermission{Plane: plane, Action: action}
+	}
+	return ret
+}
+
+// Dependencies parses the template file (and all files included with constant paths)
+// and returns all Azure template function calls without executing the template
+func (e *AzureTemplateExecutor) Dependencies(path string) ([]TemplateDependency, error) {
+	e.currentPath = path
+	return e.dependencies(e.TxtTemplate(path), path, map[string]bool{})
+}
+
+func (e *AzureTemplateExecutor) dependencies(tmpl *textTemplate.Template, path string, visited map[string]bool) ([]TemplateDependency, error) {
+	visited[path] = true
+
+	content, err := e.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to read file: %w`, err)
+	}
+
+	parsedContent, err := tmpl.New(path).Parse(string(content))
+	if err != nil {
+		return nil, e.newTemplateError(path, err)
+	}
+
+	list := []TemplateDependency{}
+	for _, call := range collectTemplateFunctionCalls(parsedContent) {
+		// templates are associated, so only use calls of this file
+		location := e.templateLocation(call.Location, path)
+		if location.File != e.displayPath(path) {
+			continue
+		}
+
+		if call.Name == "include" {
+			if len(call.Args) == 0 || call.Args[0] == nil {
+				// template function calls of the included file cannot be analyzed
+				e.logger.Warn(`path of include is dynamic, template function calls of included file are not analyzed`, slog.String("location", location.String()))
+				continue
+			}
+
+			includePath := e.fileMakePathAbs(fmt.Sprintf("%v", call.Args[0]))
+			if visited[includePath] {
+				continue
+			}
+
+			includeList, err := e.dependencies(tmpl, includePath, visited)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, includeList...)
+			continue
+		}
+
+		if dependency, ok := e.newTemplateDependency(call, location); ok {
+			list = append(list, dependency)
+		}
+	}
+
+	return list, nil
+}
+
+// newTemplateDependency builds dependency of Azure template function call (calls using azFresh and azWithProfile are resolved)
+func (e *AzureTemplateExecutor) newTemplateDependency(call templateFunctionCall, location TemplateLocation) (TemplateDependency, bool) {
+	args := call.Args
+	if call.Piped {
+		// result of previous pipeline command is passed as last argument
+		args = append(args, nil)
+	}
+
+	profile := ""
+	funcName := canonicalTemplateFunctionName(call.Name)
+	for funcName == "azFresh" || funcName == "azWithProfile" {
+		if funcName == "azWithProfile" {
+			if len(args) < 2 || args[0] == nil {
+				return TemplateDependency{}, false
+			}
+			profile = fmt.Sprintf("%v", args[0])
+			args = args[1:]
+		}
+
+		if len(args) == 0 || args[0] == nil {
+			return TemplateDependency{}, false
+		}
+		funcName = canonicalTemplateFunctionName(fmt.Sprintf("%v", args[0]))
+		args = args[1:]
+	}
+
+	functionDependency, ok := functionDependencies[funcName]
+	if !ok {
+		return TemplateDependency{}, false
+	}
+
+	ret := TemplateDependency{
+		Function:    funcName,
+		Location:    location,
+		Arguments:   args,
+		Profile:     profile,
+		Permissions: []FunctionPermission{},
+	}
+
+	for i, arg := range args {
+		if arg == nil {
+			ret.DynamicArguments = append(ret.DynamicArguments, i)
+		}
+	}
+
+	if functionDependency.resourceArgument >= 0 && functionDependency.resourceArgument < len(args) {
+		if resource := args[functionDependency.resourceArgument]; resource != nil {
+			ret.Resource = functionDependency.resourcePrefix + fmt.Sprintf("%v", resource)
+		}
+	}
+
+	for _, permission := range functionDependency.permissions {
+		if permission.Action == PermissionResourceTypeRead {
+			if action := resourceTypeReadAction(ret.Resource); action != "" {
+				permission.Action = action
+			}
+		}
+		ret.Permissions = append(ret.Permissions, permission)
+	}
+
+	ret.DynamicPermissions = append(ret.DynamicPermissions, functionDependency.dynamicPermissions...)
+
+	return ret, true
+}
+
+// resourceTypeReadAction returns the read action for the type of resourceID (eg. Microsoft.Network/virtualNetworks/read),
+// or empty if the resource type is unknown
+func resourceTypeReadAction(resourceID string) string {
+	parts := strings.Split(strings.Trim(resourceID, "/"), "/")
+
+	// find last provider namespace (eg. /subscriptions/.../providers/Microsoft.Network/virtualNetworks/foo/subnets/bar)
+	providerIndex := -1
+	for i, part := range parts {
+		if strings.EqualFold(part, "providers") {
+			providerIndex = i
+		}
+	}
+
+	if providerIndex < 0 || providerIndex+2 >= len(parts) {
+		return ""
+	}
+
+	// namespace followed by pairs of type and name
+	resourceType := []string{parts[providerIndex+1]}
+	for i := providerIndex + 2; i < len(parts); i += 2 {
+		resourceType = append(resourceType, parts[i])
+	}
+
+	return strings.Join(resourceType, "/") + "/read"
+}
@@ -0,0 +1,118 @@
+package azuretpl
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResourceTypeReadAction(t *testing.T) {
+	tests := []struct {
+		resourceID string
+		expected   string
+	}{
+		{resourceID: "/subscriptions/0000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", expected: "Microsoft.Network/virtualNetworks/read"},
+		{resourceID: "/subscriptions/0000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/default", expected: "Microsoft.Network/virtualNetworks/subnets/read"},
+		{resourceID: "/subscriptions/0000/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm/providers/Microsoft.Insights/diagnosticSettings/diag", expected: "Microsoft.Insights/diagnosticSettings/read"},
+		{resourceID: "/subscriptions/0000", expected: ""},
+		{resourceID: "", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.resourceID, func(t *testing.T) {
+			if result := resourceTypeReadAction(test.resourceID); result != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.tpl": {Data: []byte(`{{ azKeyVaultSecret "https://vault.vault.azure.net" "secret" }}
+{{ azWithProfile "prod" "azSubscription" "0000" }}
+{{ azFresh "azResource" .resourceId "2022-01-01" }}
+{{ include "included.tpl" . }}
+{{ include .dynamicPath . }}
+{{ sha256sum "not an Azure function" }}`)},
+		"included.tpl": {Data: []byte(`{{ azAppConfigSetting "https://config.azconfig.io" "key" "" }}`)},
+	}
+
+	e, err := NewFromRenderOptions(context.Background(), RenderOptions{FS: fsys})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	list, err := e.Dependencies("main.tpl")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type dependency struct {
+		Function         string
+		File             string
+		Line             int
+		Resource         string
+		Profile          string
+		DynamicArguments []int
+		Permissions      []string
+		Dynamic          []string
+	}
+
+	result := []dependency{}
+	for _, row := range list {
+		item := dependency{
+			Function:         row.Function,
+			File:             row.Location.File,
+			Line:             row.Location.Line,
+			Resource:         row.Resource,
+			Profile:          row.Profile,
+			DynamicArguments: row.DynamicArguments,
+		}
+		for _, permission := range row.Permissions {
+			item.Permissions = append(item.Permissions, permission.Plane+":"+permission.Action)
+		}
+		for _, permission := range row.DynamicPermissions {
+			item.Dynamic = append(item.Dynamic, permission.Plane+":"+permission.Action)
+		}
+		result = append(result, item)
+	}
+
+	expected := []dependency{
+		{
+			Function:    "azKeyVaultSecret",
+			File:        "main.tpl",
+			Line:        1,
+			Resource:    "https://vault.vault.azure.net",
+			Permissions: []string{"data:Microsoft.KeyVault/vaults/secrets/getSecret/action"},
+		},
+		{
+			Function:    "azSubscription",
+			File:        "main.tpl",
+			Line:        2,
+			Resource:    "/subscriptions/0000",
+			Profile:     "prod",
+			Permissions: []string{"control:Microsoft.Resources/subscriptions/read"},
+		},
+		{
+			Function:         "azResource",
+			File:             "main.tpl",
+			Line:             3,
+			DynamicArguments: []int{0},
+			Permissions:      []string{"control:" + PermissionResourceTypeRead},
+		},
+		{
+			Function:    "azAppConfigSetting",
+			File:        "included.tpl",
+			Line:        1,
+			Resource:    "https://config.azconfig.io",
+			Permissions: []string{"data:Microsoft.AppConfiguration/configurationStores/keyValues/read"},
+			Dynamic:     []string{"data:Microsoft.KeyVault/vaults/secrets/getSecret/action"},
+		},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected:\n%+v\ngot:\n%+v", expected, result)
+	}
+}
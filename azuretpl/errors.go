@@ -0,0 +1,145 @@
+package azuretpl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type (
+	// TemplateLocation is a position inside a template file
+	TemplateLocation struct {
+		File   string `json:"file"`
+		Line   int    `json:"line,omitempty"`
+		Column int    `json:"column,omitempty"`
+	}
+
+	// TemplateError is a structured error of template parsing or processing
+	TemplateError struct {
+		TemplateLocation
+
+		// Function is the template function which failed (if known)
+		Function string `json:"function,omitempty"`
+
+		// Message is the error message without location information
+		Message string `json:"message"`
+
+		// IncludeChain contains the locations of all include calls leading to the error (outermost first)
+		IncludeChain []TemplateLocation `json:"includeChain,omitempty"`
+
+		Err error `json:"-"`
+	}
+)
+
+var (
+	// eg. template: foobar.tpl:3:14: executing "foobar.tpl" at <azKeyVaultSecret "foo" "bar">: error calling azKeyVaultSecret: message
+	// or  template: foobar.tpl:3: function "foo" not defined
+	templateErrorRegexp     = regexp.MustCompile(`(?s)^template: (.+?):(\d+)(?::(\d+))?: (.*)$`)
+	templateErrorExecRegexp = regexp.MustCompile(`(?s)^executing "[^"]*" at <(.*?)>: (.*)$`)
+	templateErrorCallRegexp = regexp.MustCompile(`(?s)^error calling (\S+): (.*)$`)
+	templateFunctionRegexp  = regexp.MustCompile(`^[a-zA-Z_]\w*$`)
+
+	// eg. foobar.tpl:3:14 (location of parsed nodes)
+	templateLocationRegexp = regexp.MustCompile(`^(.*):(\d+):(\d+)$`)
+)
+
+func (l TemplateLocation) String() string {
+	switch {
+	case l.Column > 0:
+		return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+	case l.Line > 0:
+		return fmt.Sprintf("%s:%d", l.File, l.Line)
+	default:
+		return l.File
+	}
+}
+
+func (e *TemplateError) Error() string {
+	msg := e.TemplateLocation.String() + ": "
+	if e.Function != "" {
+		msg += e.Function + ": "
+	}
+	msg += e.Message
+
+	for i := len(e.IncludeChain) - 1; i >= 0; i-- {
+		msg += fmt.Sprintf(" (included from %s)", e.IncludeChain[i].String())
+	}
+
+	return msg
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// newTemplateError converts text/template parse and exec errors into structured errors,
+// errors of included templates are returned with the include chain
+func (e *AzureTemplateExecutor) newTemplateError(file string, err error) *TemplateError {
+	ret := &TemplateError{
+		TemplateLocation: TemplateLocation{
+			File: e.displayPath(file),
+		},
+		Message: err.Error(),
+		Err:     err,
+	}
+
+	if match := templateErrorRegexp.FindStringSubmatch(err.Error()); match != nil {
+		ret.Line, _ = strconv.Atoi(match[2])   // nolint: errcheck
+		ret.Column, _ = strconv.Atoi(match[3]) // nolint: errcheck
+		ret.Message = match[4]
+
+		if execMatch := templateErrorExecRegexp.FindStringSubmatch(ret.Message); execMatch != nil {
+			// only function calls, not field or variable access (eg. .Values.foo)
+			if funcName := strings.SplitN(execMatch[1], " ", 2)[0]; templateFunctionRegexp.MatchString(funcName) {
+				ret.Function = funcName
+			}
+			ret.Message = execMatch[2]
+		}
+
+		if callMatch := templateErrorCallRegexp.FindStringSubmatch(ret.Message); callMatch != nil {
+			ret.Function = callMatch[1]
+			ret.Message = callMatch[2]
+		}
+	}
+
+	// error inside included template, use location of the included template
+	var innerErr *TemplateError
+	if errors.As(err, &innerErr) {
+		inner := *innerErr
+		inner.IncludeChain = append([]TemplateLocation{ret.TemplateLocation}, innerErr.IncludeChain...)
+		inner.Err = err
+		return &inner
+	}
+
+	return ret
+}
+
+// templateLocation converts the location of a parsed node (file:line:col) into TemplateLocation
+func (e *AzureTemplateExecutor) templateLocation(location string, defaultFile string) TemplateLocation {
+	ret := TemplateLocation{File: e.displayPath(defaultFile)}
+	if match := templateLocationRegexp.FindStringSubmatch(location); match != nil {
+		ret.File = e.displayPath(match[1])
+		ret.Line, _ = strconv.Atoi(match[2])   // nolint: errcheck
+		ret.Column, _ = strconv.Atoi(match[3]) // nolint: errcheck
+	}
+	return ret
+}
+
+// displayPath returns path relative to the current directory (if possible) for error messages and annotations
+func (e *AzureTemplateExecutor) displayPath(path string) string {
+	if e.fsys != nil || !filepath.IsAbs(path) {
+		return path
+	}
+
+	if pwd, err := os.Getwd(); err == nil {
+		if relPath, err := filepath.Rel(pwd, path); err == nil && !strings.HasPrefix(relPath, "..") {
+			return relPath
+		}
+	}
+
+	return path
+}
@@ -2,12 +2,32 @@ package azuretpl
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
 
+// fileCleanPath returns cleaned absolute path (or cleaned path inside the filesystem if set)
+func (e *AzureTemplateExecutor) fileCleanPath(val string) (string, error) {
+	if e.fsys != nil {
+		val = path.Clean("/" + filepath.ToSlash(val))
+		val = strings.TrimLeft(val, "/")
+		if val == "" {
+			val = "."
+		}
+		return val, nil
+	}
+
+	return filepath.Abs(filepath.Clean(val))
+}
+
 func (e *AzureTemplateExecutor) fileMakePathAbs(path string) string {
+	if e.fsys != nil {
+		return e.fsMakePath(path)
+	}
+
 	path = filepath.Clean(path)
 
 	if filepath.IsAbs(path) {
@@ -27,10 +47,47 @@ func (e *AzureTemplateExecutor) fileMakePathAbs(path string) string {
 	return path
 }
 
+// fsMakePath builds path inside the filesystem, absolute paths are relative to the template root path
+func (e *AzureTemplateExecutor) fsMakePath(val string) string {
+	val = filepath.ToSlash(val)
+
+	basePath := e.TemplateRelPath
+	if strings.HasPrefix(val, "/") {
+		basePath = e.TemplateRootPath
+	}
+
+	if basePath == "" {
+		basePath = "."
+	}
+
+	return path.Join(basePath, strings.TrimLeft(val, "/"))
+}
+
+// fileMakePathRel makes path relative to the template root path
+func (e *AzureTemplateExecutor) fileMakePathRel(val string) string {
+	if e.fsys != nil {
+		if e.TemplateRootPath != "" && e.TemplateRootPath != "." {
+			val = strings.TrimPrefix(val, e.TemplateRootPath)
+		}
+		return fmt.Sprintf("./%s", strings.TrimLeft(val, "/"))
+	}
+
+	return fmt.Sprintf(".%s%s", string(os.PathSeparator), strings.TrimLeft(strings.TrimPrefix(val, e.TemplateRootPath), string(os.PathSeparator)))
+}
+
+// readFile reads file from filesystem (if set) or from local disk
+func (e *AzureTemplateExecutor) readFile(path string) ([]byte, error) {
+	if e.fsys != nil {
+		return fs.ReadFile(e.fsys, path)
+	}
+
+	return os.ReadFile(path)
+}
+
 func (e *AzureTemplateExecutor) filesGet(path string) (string, error) {
 	sourcePath := e.fileMakePathAbs(path)
 
-	content, err := os.ReadFile(sourcePath)
+	content, err := e.readFile(sourcePath)
 	if err != nil {
 		return "", fmt.Errorf(`unable to read file: %w`, err)
 	}
@@ -40,7 +97,16 @@ func (e *AzureTemplateExecutor) filesGet(path string) (string, error) {
 
 func (e *AzureTemplateExecutor) filesGlob(pattern string) (interface{}, error) {
 	pattern = e.fileMakePathAbs(pattern)
-	matches, err := filepath.Glob(pattern)
+
+	var (
+		matches []string
+		err     error
+	)
+	if e.fsys != nil {
+		matches, err = fs.Glob(e.fsys, pattern)
+	} else {
+		matches, err = filepath.Glob(pattern)
+	}
 	if err != nil {
 		return nil, fmt.Errorf(
 			`failed to parse glob pattern '%v': %w`,
@@ -51,15 +117,19 @@ func (e *AzureTemplateExecutor) filesGlob(pattern string) (interface{}, error) {
 
 	var ret []string
 	for _, path := range matches {
-		fileInfo, err := os.Stat(path)
+		var fileInfo fs.FileInfo
+		if e.fsys != nil {
+			fileInfo, err = fs.Stat(e.fsys, path)
+		} else {
+			fileInfo, err = os.Stat(path)
+		}
 		if err != nil {
 			return nil, err
 		}
 
 		if !fileInfo.IsDir() {
 			// make path relative
-			path = fmt.Sprintf(".%s%s", string(os.PathSeparator), strings.TrimLeft(strings.TrimPrefix(path, e.TemplateRootPath), string(os.PathSeparator)))
-			ret = append(ret, path)
+			ret = append(ret, e.fileMakePathRel(path))
 		}
 	}
 
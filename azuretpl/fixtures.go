@@ -0,0 +1,177 @@
+package azuretpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/webdevops/go-common/azuresdk/cloudconfig"
+)
+
+const (
+	FixtureVersion       = 2
+	FixtureRedactedValue = "<redacted>"
+)
+
+type (
+	// FixtureFile is the on-disk format of recorded template function results
+	FixtureFile struct {
+		Version   int                    `json:"version"`
+		CloudName string                 `json:"cloudName,omitempty"`
+		Entries   map[string]interface{} `json:"entries"`
+	}
+
+	fixtureStore struct {
+		lock   sync.RWMutex
+		file   FixtureFile
+		replay bool
+	}
+)
+
+var (
+	fixtures = newFixtureStore()
+)
+
+func newFixtureStore() *fixtureStore {
+	return &fixtureStore{
+		file: FixtureFile{
+			Version: FixtureVersion,
+			Entries: map[string]interface{}{},
+		},
+	}
+}
+
+// LoadFixtures loads recorded template function results and enables replay mode
+func LoadFixtures(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf(`unable to read fixture file "%v": %w`, path, err)
+	}
+
+	file := FixtureFile{}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf(`unable to parse fixture file "%v": %w`, path, err)
+	}
+
+	if file.Version != FixtureVersion {
+		return fmt.Errorf(`unsupported fixture file version "%v" in "%v", expected version %v`, file.Version, path, FixtureVersion)
+	}
+
+	if file.Entries == nil {
+		file.Entries = map[string]interface{}{}
+	}
+
+	fixtures.lock.Lock()
+	defer fixtures.lock.Unlock()
+	fixtures.file = file
+	fixtures.replay = true
+
+	return nil
+}
+
+// SaveFixtures writes all recorded template function results to path
+func SaveFixtures(path string) error {
+	fixtures.lock.RLock()
+	defer fixtures.lock.RUnlock()
+
+	content, err := json.MarshalIndent(fixtures.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf(`unable to marshal fixtures: %w`, err)
+	}
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf(`unable to write fixture file "%v": %w`, path, err)
+	}
+
+	return nil
+}
+
+// isReplay returns true if results are served from a fixture file
+func (s *fixtureStore) isReplay() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.replay
+}
+
+// get returns recorded result for cacheKey
+func (s *fixtureStore) get(cacheKey string) (interface{}, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if val, ok := s.file.Entries[cacheKey]; ok {
+		return val, nil
+	}
+
+	return nil, fmt.Errorf(`no fixture recorded for "%v", please record fixtures again`, cacheKey)
+}
+
+// record stores result for cacheKey, secret values are redacted if enabled
+func (s *fixtureStore) record(cacheKey string, val interface{}, redactSecrets bool) error {
+	// store json representation to get the same result as in replay mode
+	val, err := transformToInterface(val)
+	if err != nil {
+		return fmt.Errorf(`unable to record fixture "%v": %w`, cacheKey, err)
+	}
+
+	if redactSecrets {
+		funcName := cacheKeyFunctionName(cacheKey)
+		if secretFunctions[funcName] {
+			val = redactFixtureValue(funcName, val)
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.file.Entries[cacheKey] = val
+
+	return nil
+}
+
+// cloudName returns recorded Azure cloud name
+func (s *fixtureStore) cloudName() cloudconfig.CloudName {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return cloudconfig.CloudName(s.file.CloudName)
+}
+
+// setCloudName records Azure cloud name (used for building KeyVault and AppConfig urls)
+func (s *fixtureStore) setCloudName(val cloudconfig.CloudName) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.file.CloudName = string(val)
+}
+
+// redactFixtureValue replaces secret values inside a recorded result
+func redactFixtureValue(funcName string, val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		// only keyvault references are secrets inside AppConfig
+		if funcName == `azAppConfigSetting` {
+			if contentType, ok := v["contentType"].(string); !ok || !isAppConfigKeyVaultReference(contentType) {
+				return v
+			}
+		}
+
+		for key, item := range v {
+			if _, isString := item.(string); isString && secretResultFields[key] {
+				v[key] = FixtureRedactedValue
+			} else {
+				v[key] = redactFixtureValue(funcName, item)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			if _, isString := item.(string); isString {
+				// plain list of keys (eg. Redis access keys)
+				v[i] = FixtureRedactedValue
+			} else {
+				v[i] = redactFixtureValue(funcName, item)
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
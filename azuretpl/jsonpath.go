@@ -11,8 +11,10 @@ func (e *AzureTemplateExecutor) jsonPath(jsonPath string, v interface{}) (interf
 
 	if v, enabled := e.lintResult(); enabled {
 		// validate jsonpath
-		_, err := jsonpath.Language().NewEvaluableWithContext(e.ctx, jsonPath)
-		return v, err
+		if _, err := jsonpath.Language().NewEvaluableWithContext(e.ctx, jsonPath); err != nil {
+			return v, e.lintFinding(LintRuleInvalidJsonPath, fmt.Sprintf(`invalid jsonpath '%v': %v`, jsonPath, err))
+		}
+		return v, nil
 	}
 
 	ret, err := jsonpath.Get(jsonPath, v)
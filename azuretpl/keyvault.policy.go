@@ -0,0 +1,323 @@
+package azuretpl
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/webdevops/go-common/utils/to"
+	"sigs.k8s.io/yaml"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
+)
+
+const (
+	KeyVaultPolicyRuleExpiry        = "expiry"
+	KeyVaultPolicyRuleRequireExpiry = "require-expiry"
+	KeyVaultPolicyRuleMaxAge        = "max-age"
+)
+
+type (
+	// KeyVaultPolicy is the expiry policy for Azure KeyVault secrets (see --keyvault.policy),
+	// overrides are applied in order for matching vaults and secret names
+	KeyVaultPolicy struct {
+		KeyVaultPolicyRules
+
+		Overrides []*KeyVaultPolicyOverride `json:"overrides"`
+	}
+
+	// KeyVaultPolicyRules are the rules of the expiry policy, unset rules are inherited
+	KeyVaultPolicyRules struct {
+		// ExpiryDays reports secrets expiring within this number of days
+		ExpiryDays *int `json:"expiryDays"`
+
+		// RequireExpiry reports secrets without expiry date
+		RequireExpiry *bool `json:"requireExpiry"`
+
+		// MaxAgeDays reports secrets created more than this number of days ago
+		MaxAgeDays *int `json:"maxAgeDays"`
+
+		// Level of expiry and requireExpiry violations (error or warning, default error)
+		Level *string `json:"level"`
+
+		// MaxAgeLevel is the level of maxAge violations (error or warning, default warning)
+		MaxAgeLevel *string `json:"maxAgeLevel"`
+	}
+
+	// KeyVaultPolicyOverride overrides rules for vaults and secrets matching the patterns (regexp)
+	KeyVaultPolicyOverride struct {
+		// Vault is matched against vault name and url (empty matches all vaults)
+		Vault string `json:"vault"`
+
+		// Secret is matched against secret name (empty matches all secrets)
+		Secret string `json:"secret"`
+
+		KeyVaultPolicyRules
+
+		vaultRegexp  *regexp.Regexp
+		secretRegexp *regexp.Regexp
+	}
+
+	// KeyVaultPolicyViolation is a secret violating the expiry policy
+	KeyVaultPolicyViolation struct {
+		Level   string `json:"level"`
+		Rule    string `json:"rule"`
+		Vault   string `json:"vault"`
+		Secret  string `json:"secret"`
+		Version string `json:"version"`
+		Message string `json:"message"`
+
+		// Reference is the AppConfig setting referencing the secret (empty if the secret is used directly)
+		Reference string `json:"reference,omitempty"`
+	}
+)
+
+var (
+	// ErrKeyVaultPolicyViolated is returned by Parse if secrets are violating the expiry policy with level error,
+	// violations are available as findings (see AzureTemplateExecutor.LintFindings)
+	ErrKeyVaultPolicyViolated = errors.New(`keyvault expiry policy violated`)
+
+	keyVaultPolicies     = map[string]*KeyVaultPolicy{}
+	keyVaultPoliciesLock sync.Mutex
+)
+
+// loadKeyVaultPolicyFile reads the expiry policy file (yaml or json), files are only read once
+func loadKeyVaultPolicyFile(path string) (*KeyVaultPolicy, error) {
+	keyVaultPoliciesLock.Lock()
+	defer keyVaultPoliciesLock.Unlock()
+
+	if policy, exists := keyVaultPolicies[path]; exists {
+		return policy, nil
+	}
+
+	content, err := os.ReadFile(path) // #nosec G304 policy file is set by user
+	if err != nil {
+		return nil, fmt.Errorf(`unable to read KeyVault policy file "%v": %w`, path, err)
+	}
+
+	policy := &KeyVaultPolicy{}
+	if err := yaml.UnmarshalStrict(content, policy); err != nil {
+		return nil, fmt.Errorf(`unable to parse KeyVault policy file "%v": %w`, path, err)
+	}
+
+	if err := policy.KeyVaultPolicyRules.validate(); err != nil {
+		return nil, fmt.Errorf(`invalid KeyVault policy file "%v": %w`, path, err)
+	}
+
+	for i, override := range policy.Overrides {
+		if override == nil {
+			return nil, fmt.Errorf(`invalid KeyVault policy file "%v": override %d is empty`, path, i)
+		}
+
+		if err := override.KeyVaultPolicyRules.validate(); err != nil {
+			return nil, fmt.Errorf(`invalid KeyVault policy file "%v": override %d: %w`, path, i, err)
+		}
+
+		if override.vaultRegexp, err = regexp.Compile(override.Vault); err != nil {
+			return nil, fmt.Errorf(`invalid KeyVault policy file "%v": override %d: unable to compile vault pattern: %w`, path, i, err)
+		}
+
+		if override.secretRegexp, err = regexp.Compile(override.Secret); err != nil {
+			return nil, fmt.Errorf(`invalid KeyVault policy file "%v": override %d: unable to compile secret pattern: %w`, path, i, err)
+		}
+	}
+
+	keyVaultPolicies[path] = policy
+	return policy, nil
+}
+
+func (r *KeyVaultPolicyRules) validate() error {
+	for name, level := range map[string]*string{"level": r.Level, "maxAgeLevel": r.MaxAgeLevel} {
+		if level != nil && *level != LintLevelError && *level != LintLevelWarning {
+			return fmt.Errorf(`%v must be "%v" or "%v", got "%v"`, name, LintLevelError, LintLevelWarning, *level)
+		}
+	}
+	return nil
+}
+
+// merge overrides all rules which are set in override
+func (r KeyVaultPolicyRules) merge(override KeyVaultPolicyRules) KeyVaultPolicyRules {
+	if override.ExpiryDays != nil {
+		r.ExpiryDays = override.ExpiryDays
+	}
+	if override.RequireExpiry != nil {
+		r.RequireExpiry = override.RequireExpiry
+	}
+	if override.MaxAgeDays != nil {
+		r.MaxAgeDays = override.MaxAgeDays
+	}
+	if override.Level != nil {
+		r.Level = override.Level
+	}
+	if override.MaxAgeLevel != nil {
+		r.MaxAgeLevel = override.MaxAgeLevel
+	}
+	return r
+}
+
+// rules returns the effective rules for secret inside vault
+func (p *KeyVaultPolicy) rules(vaultUrl, secretName string) KeyVaultPolicyRules {
+	vaultName := vaultUrl
+	if parsedUrl, err := url.Parse(vaultUrl); err == nil && parsedUrl.Host != "" {
+		vaultName = strings.SplitN(parsedUrl.Host, ".", 2)[0]
+	}
+
+	ret := p.KeyVaultPolicyRules
+	for _, override := range p.Overrides {
+		if !override.vaultRegexp.MatchString(vaultName) && !override.vaultRegexp.MatchString(vaultUrl) {
+			continue
+		}
+
+		if !override.secretRegexp.MatchString(secretName) {
+			continue
+		}
+
+		ret = ret.merge(override.KeyVaultPolicyRules)
+	}
+	return ret
+}
+
+// evaluate returns all violations of secret
+func (p *KeyVaultPolicy) evaluate(vaultUrl string, secret *models.AzSecret) []KeyVaultPolicyViolation {
+	rules := p.rules(vaultUrl, secret.Name)
+
+	level := LintLevelError
+	if rules.Level != nil {
+		level = *rules.Level
+	}
+
+	maxAgeLevel := LintLevelWarning
+	if rules.MaxAgeLevel != nil {
+		maxAgeLevel = *rules.MaxAgeLevel
+	}
+
+	var expires, created *time.Time
+	if secret.Attributes != nil {
+		expires = secret.Attributes.Expires
+		created = secret.Attributes.Created
+	}
+
+	ret := []KeyVaultPolicyViolation{}
+	violation := func(level, rule, message string) {
+		ret = append(ret, KeyVaultPolicyViolation{
+			Level:   level,
+			Rule:    rule,
+			Vault:   vaultUrl,
+			Secret:  secret.Name,
+			Version: secret.Version,
+			Message: fmt.Sprintf(`Azure KeyVault secret '%v' -> '%v' violates expiry policy: %v`, vaultUrl, secret.Name, message),
+		})
+	}
+
+	if rules.RequireExpiry != nil && *rules.RequireExpiry && expires == nil {
+		violation(level, KeyVaultPolicyRuleRequireExpiry, `secret has no expiry date`)
+	}
+
+	if rules.ExpiryDays != nil && expires != nil && time.Now().AddDate(0, 0, *rules.ExpiryDays).After(*expires) {
+		violation(level, KeyVaultPolicyRuleExpiry, fmt.Sprintf(`secret expires within %d days (expires: %v)`, *rules.ExpiryDays, expires.Format(time.RFC3339)))
+	}
+
+	if rules.MaxAgeDays != nil && created != nil && time.Now().AddDate(0, 0, -*rules.MaxAgeDays).After(*created) {
+		violation(maxAgeLevel, KeyVaultPolicyRuleMaxAge, fmt.Sprintf(`secret is older than %d days (created: %v)`, *rules.MaxAgeDays, created.Format(time.RFC3339)))
+	}
+
+	return ret
+}
+
+// keyVaultPolicy returns the expiry policy (nil if no policy is set)
+func (e *AzureTemplateExecutor) keyVaultPolicy() (*KeyVaultPolicy, error) {
+	if e.opts.Keyvault.Policy == "" {
+		return nil, nil
+	}
+	return loadKeyVaultPolicyFile(e.opts.Keyvault.Policy)
+}
+
+// checkKeyVaultSecretPolicy checks secret (result of azKeyVaultSecret) against the expiry policy,
+// violations are added to the summary and findings, the template processing fails after execution for violations with level error
+func (e *AzureTemplateExecutor) checkKeyVaultSecretPolicy(vaultUrl string, val interface{}) error {
+	policy, err := e.keyVaultPolicy()
+	if err != nil || policy == nil {
+		return err
+	}
+
+	secret, err := parseKeyVaultSecretListItem(val)
+	if err != nil {
+		return fmt.Errorf(`unable to check KeyVault policy: %w`, err)
+	}
+
+	for _, violation := range policy.evaluate(vaultUrl, secret) {
+		e.addKeyVaultPolicyViolation(`azKeyVaultSecret`, violation)
+	}
+
+	return nil
+}
+
+// checkAppConfigKeyVaultReferencePolicy checks the secret referenced by an AppConfig setting against the expiry policy,
+// function is the template function which fetched the setting
+func (e *AzureTemplateExecutor) checkAppConfigKeyVaultReferencePolicy(function, appConfigUrl string, setting *models.AzAppconfigSetting) error {
+	policy, err := e.keyVaultPolicy()
+	if err != nil || policy == nil {
+		return err
+	}
+
+	reference := setting.KeyVaultReference
+	secret := &models.AzSecret{
+		Name:    reference.SecretName,
+		Version: reference.Version,
+		Attributes: &azsecrets.SecretAttributes{
+			Created: reference.Created,
+			Expires: reference.Expires,
+		},
+	}
+
+	referenceId := appConfigSettingLockId(appConfigUrl, to.String(setting.Key), to.String(setting.Label))
+	for _, violation := range policy.evaluate(reference.VaultUrl, secret) {
+		violation.Reference = referenceId
+		violation.Message = fmt.Sprintf(`%v (referenced by AppConfig setting '%v')`, violation.Message, referenceId)
+		e.addKeyVaultPolicyViolation(function, violation)
+	}
+
+	return nil
+}
+
+func (e *AzureTemplateExecutor) addKeyVaultPolicyViolation(function string, violation KeyVaultPolicyViolation) {
+	if violation.Level == LintLevelError {
+		e.logger.Error(violation.Message, slog.String("rule", violation.Rule))
+	} else {
+		e.logger.Warn(e.handleCicdWarning(errors.New(violation.Message)), slog.String("rule", violation.Rule))
+	}
+
+	e.addSummaryKeyvaultPolicyViolation(violation)
+
+	e.appendLintFinding(LintFinding{
+		Level: violation.Level,
+		Rule:  LintRuleKeyVaultPolicy,
+		TemplateError: TemplateError{
+			TemplateLocation: TemplateLocation{File: e.displayPath(e.currentPath)},
+			Function:         function,
+			Message:          violation.Message,
+		},
+	})
+}
+
+// keyVaultPolicyError returns ErrKeyVaultPolicyViolated if findings contain policy violations with level error
+func (e *AzureTemplateExecutor) keyVaultPolicyError() error {
+	count := 0
+	for _, finding := range e.LintFindings() {
+		if finding.Rule == LintRuleKeyVaultPolicy && finding.Level == LintLevelError {
+			count++
+		}
+	}
+
+	if count > 0 {
+		return fmt.Errorf(`%w: found %d violations`, ErrKeyVaultPolicyViolated, count)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package azuretpl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
+)
+
+func writeTestKeyVaultPolicy(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestKeyVaultPolicyRules(t *testing.T) {
+	policy, err := loadKeyVaultPolicyFile(writeTestKeyVaultPolicy(t, `
+expiryDays: 30
+requireExpiry: true
+overrides:
+  - vault: "^prod-"
+    expiryDays: 60
+    level: warning
+  - vault: "^prod-"
+    secret: "^legacy-"
+    requireExpiry: false
+  - vault: "https://shared\\.vault\\.azure\\.net"
+    maxAgeDays: 90
+    maxAgeLevel: error
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type rules struct {
+		ExpiryDays    int
+		RequireExpiry bool
+		MaxAgeDays    int
+		Level         string
+		MaxAgeLevel   string
+	}
+
+	tests := []struct {
+		name     string
+		vaultUrl string
+		secret   string
+		expected rules
+	}{
+		{name: "defaults", vaultUrl: "https://dev-app.vault.azure.net", secret: "password", expected: rules{ExpiryDays: 30, RequireExpiry: true}},
+		{name: "vault override", vaultUrl: "https://prod-app.vault.azure.net", secret: "password", expected: rules{ExpiryDays: 60, RequireExpiry: true, Level: "warning"}},
+		{name: "vault and secret override", vaultUrl: "https://prod-app.vault.azure.net", secret: "legacy-password", expected: rules{ExpiryDays: 60, RequireExpiry: false, Level: "warning"}},
+		{name: "override matching vault url", vaultUrl: "https://shared.vault.azure.net", secret: "password", expected: rules{ExpiryDays: 30, RequireExpiry: true, MaxAgeDays: 90, MaxAgeLevel: "error"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := policy.rules(test.vaultUrl, test.secret)
+
+			actual := rules{}
+			if result.ExpiryDays != nil {
+				actual.ExpiryDays = *result.ExpiryDays
+			}
+			if result.RequireExpiry != nil {
+				actual.RequireExpiry = *result.RequireExpiry
+			}
+			if result.MaxAgeDays != nil {
+				actual.MaxAgeDays = *result.MaxAgeDays
+			}
+			if result.Level != nil {
+				actual.Level = *result.Level
+			}
+			if result.MaxAgeLevel != nil {
+				actual.MaxAgeLevel = *result.MaxAgeLevel
+			}
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestKeyVaultPolicyEvaluate(t *testing.T) {
+	policy, err := loadKeyVaultPolicyFile(writeTestKeyVaultPolicy(t, `
+expiryDays: 30
+requireExpiry: true
+maxAgeDays: 365
+overrides:
+  - secret: "^optional$"
+    requireExpiry: false
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	soon := now.AddDate(0, 0, 10)
+	later := now.AddDate(0, 0, 100)
+	old := now.AddDate(-2, 0, 0)
+
+	tests := []struct {
+		name       string
+		secret     string
+		attributes *azsecrets.SecretAttributes
+		expected   []string
+	}{
+		{name: "valid", secret: "password", attributes: &azsecrets.SecretAttributes{Expires: &later, Created: &now}, expected: []string{}},
+		{name: "missing expiry", secret: "password", attributes: &azsecrets.SecretAttributes{Created: &now}, expected: []string{"error:require-expiry"}},
+		{name: "missing attributes", secret: "password", expected: []string{"error:require-expiry"}},
+		{name: "expiry not required", secret: "optional", attributes: &azsecrets.SecretAttributes{Created: &now}, expected: []string{}},
+		{name: "expiring and old", secret: "password", attributes: &azsecrets.SecretAttributes{Expires: &soon, Created: &old}, expected: []string{"error:expiry", "warning:max-age"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := &models.AzSecret{Name: test.secret, Attributes: test.attributes}
+
+			result := []string{}
+			for _, violation := range policy.evaluate("https://vault.vault.azure.net", secret) {
+				result = append(result, violation.Level+":"+violation.Rule)
+			}
+
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestLoadKeyVaultPolicyFileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "invalid level", content: `level: fatal`},
+		{name: "invalid override level", content: "overrides:\n  - maxAgeLevel: info"},
+		{name: "invalid pattern", content: "overrides:\n  - vault: \"[\""},
+		{name: "empty override", content: "overrides:\n  -"},
+		{name: "unknown field", content: `expiry: 30`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := loadKeyVaultPolicyFile(writeTestKeyVaultPolicy(t, test.content)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
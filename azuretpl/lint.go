@@ -0,0 +1,206 @@
+package azuretpl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	textTemplate "text/template"
+)
+
+const (
+	LintLevelError   = "error"
+	LintLevelWarning = "warning"
+
+	LintRuleParseError      = "parse-error"
+	LintRuleUnknownFunction = "unknown-function"
+	LintRuleInvalidJsonPath = "invalid-jsonpath"
+	LintRuleInvalidRegexp   = "invalid-regexp"
+	LintRuleRequired        = "required"
+	LintRuleFail            = "fail"
+	LintRuleTemplateError   = "template-error"
+
+	// LintRuleKeyVaultPolicy is used for violations of the KeyVault expiry policy (see --keyvault.policy),
+	// they are only found when rendering templates as secrets are not fetched in lint mode
+	LintRuleKeyVaultPolicy = "keyvault-policy"
+
+	// lintMaxFindings limits the number of findings per template file (every finding needs another execution of the template)
+	lintMaxFindings = 100
+)
+
+type (
+	// LintFinding is a problem found while linting a template
+	LintFinding struct {
+		Level string `json:"level"`
+		Rule  string `json:"rule"`
+		TemplateError
+	}
+
+	// lintState collects findings of one template file, it's shared with copies of the executor (see azFresh)
+	lintState struct {
+		lock     sync.Mutex
+		findings []LintFinding
+
+		// hits counts the findings of template functions during the current execution,
+		// the first skip hits are already known and are passed silently to continue the execution
+		hits int
+		skip int
+	}
+
+	// lintHitError stops the template execution to get the location of a finding
+	lintHitError struct {
+		rule    string
+		message string
+	}
+)
+
+var (
+	lintUnknownFunctionRegexp = regexp.MustCompile(`function "([^"]+)" not defined`)
+
+	// lintRuleLevels are the levels of findings, required and fail are not failing in lint mode
+	lintRuleLevels = map[string]string{
+		LintRuleRequired: LintLevelWarning,
+		LintRuleFail:     LintLevelWarning,
+	}
+)
+
+func (err *lintHitError) Error() string {
+	return err.message
+}
+
+// LintFindings returns all findings of the linted templates
+func (e *AzureTemplateExecutor) LintFindings() []LintFinding {
+	e.lint.lock.Lock()
+	defer e.lint.lock.Unlock()
+	return append([]LintFinding{}, e.lint.findings...)
+}
+
+// lintFinding reports a finding of a template function in lint mode,
+// returns an error for new findings so the location is known from the execution error
+func (e *AzureTemplateExecutor) lintFinding(rule, message string) error {
+	e.lint.lock.Lock()
+	defer e.lint.lock.Unlock()
+
+	e.lint.hits++
+	if e.lint.hits <= e.lint.skip {
+		return nil
+	}
+
+	return &lintHitError{rule: rule, message: message}
+}
+
+// addLintFinding adds finding (duplicates are ignored, eg. from templates included multiple times)
+func (e *AzureTemplateExecutor) addLintFinding(rule string, templateErr *TemplateError) {
+	finding := LintFinding{
+		Level:         LintLevelError,
+		Rule:          rule,
+		TemplateError: *templateErr,
+	}
+	if level, ok := lintRuleLevels[rule]; ok {
+		finding.Level = level
+	}
+
+	e.appendLintFinding(finding)
+}
+
+func (e *AzureTemplateExecutor) appendLintFinding(finding LintFinding) {
+	e.lint.lock.Lock()
+	defer e.lint.lock.Unlock()
+
+	for _, existing := range e.lint.findings {
+		if existing.Rule == finding.Rule && existing.TemplateLocation == finding.TemplateLocation && existing.Message == finding.Message {
+			return
+		}
+	}
+	e.lint.findings = append(e.lint.findings, finding)
+}
+
+// parseTemplate parses content as new template name associated with tmpl,
+// in lint mode unknown functions are reported and replaced by placeholders so linting can continue
+func (e *AzureTemplateExecutor) parseTemplate(tmpl *textTemplate.Template, name, content string) (*textTemplate.Template, error) {
+	if !e.LintMode {
+		return tmpl.New(name).Parse(content)
+	}
+
+	unknownFunctions := map[string]bool{}
+	for {
+		parsedContent, err := tmpl.New(name).Parse(content)
+		if err == nil {
+			e.lintUnknownFunctions(parsedContent, name, unknownFunctions)
+			return parsedContent, nil
+		}
+
+		match := lintUnknownFunctionRegexp.FindStringSubmatch(err.Error())
+		if match == nil || unknownFunctions[match[1]] {
+			return nil, err
+		}
+
+		unknownFunctions[match[1]] = true
+		tmpl.Funcs(textTemplate.FuncMap{
+			match[1]: func(args ...interface{}) (interface{}, error) {
+				return nil, nil
+			},
+		})
+	}
+}
+
+// lintUnknownFunctions adds findings for all calls of unknown functions
+func (e *AzureTemplateExecutor) lintUnknownFunctions(tmpl *textTemplate.Template, name string, unknownFunctions map[string]bool) {
+	if len(unknownFunctions) == 0 {
+		return
+	}
+
+	for _, call := range collectTemplateFunctionCalls(tmpl) {
+		if !unknownFunctions[call.Name] {
+			continue
+		}
+
+		// location of calls inside associated templates (eg. included files) is containing their name
+		templateErr := &TemplateError{
+			TemplateLocation: e.templateLocation(call.Location, name),
+			Function:         call.Name,
+			Message:          fmt.Sprintf(`function "%v" not defined`, call.Name),
+		}
+		e.addLintFinding(LintRuleUnknownFunction, templateErr)
+	}
+}
+
+// lintExecute executes the template until all findings are collected,
+// every finding of a template function stops the execution, so the template is executed again and known findings are skipped
+func (e *AzureTemplateExecutor) lintExecute(name string, tmpl *textTemplate.Template, templateData interface{}, w io.Writer) {
+	for {
+		e.lint.lock.Lock()
+		e.lint.hits = 0
+		e.lint.lock.Unlock()
+
+		var buf bytes.Buffer
+		err := tmpl.Execute(&buf, templateData)
+		if err == nil {
+			w.Write(buf.Bytes()) // nolint: errcheck
+			return
+		}
+
+		templateErr := e.newTemplateError(name, err)
+
+		var hitErr *lintHitError
+		if !errors.As(err, &hitErr) {
+			e.addLintFinding(LintRuleTemplateError, templateErr)
+			return
+		}
+
+		templateErr.Message = hitErr.message
+		e.addLintFinding(hitErr.rule, templateErr)
+
+		e.lint.lock.Lock()
+		e.lint.skip++
+		skip := e.lint.skip
+		e.lint.lock.Unlock()
+
+		if skip >= lintMaxFindings {
+			e.logger.Warn(fmt.Sprintf(`too many findings, stopping lint of "%v"`, name))
+			return
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package azuretpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+const (
+	LockFileVersion = 1
+
+	LockEntryKeyVaultSecret   = "keyVaultSecret"
+	LockEntryAppConfigSetting = "appConfigSetting"
+
+	LockChangeAdded   = "added"
+	LockChangeChanged = "changed"
+	LockChangeRemoved = "removed"
+)
+
+type (
+	// LockFile pins the versions of Azure KeyVault secrets and the ETags of AppConfig settings per template (see --locked)
+	LockFile struct {
+		Version   int                          `json:"version"`
+		Templates map[string]*LockFileTemplate `json:"templates"`
+	}
+
+	// LockFileTemplate contains the pinned entries of one template file
+	LockFileTemplate struct {
+		// KeyVaultSecrets are the secret versions by secret id (<vaultUrl>/secrets/<name>)
+		KeyVaultSecrets map[string]string `json:"keyVaultSecrets,omitempty"`
+
+		// AppConfigSettings are the setting ETags by setting id (<appConfigUrl>/kv/<key>?label=<label>)
+		AppConfigSettings map[string]string `json:"appConfigSettings,omitempty"`
+	}
+
+	// LockFileChange is an entry which was added, changed or removed by UpdateLockFile
+	LockFileChange struct {
+		Template string `json:"template"`
+		Type     string `json:"type"`
+		Id       string `json:"id"`
+		Status   string `json:"status"`
+		Old      string `json:"old,omitempty"`
+		New      string `json:"new,omitempty"`
+	}
+
+	lockStore struct {
+		lock sync.RWMutex
+
+		// pinned are the entries of the lock file (only used in locked mode)
+		pinned *LockFile
+		locked bool
+
+		// resolved are the entries used while processing templates
+		resolved *LockFile
+	}
+)
+
+var (
+	lockFile = newLockStore()
+)
+
+func newLockStore() *lockStore {
+	return &lockStore{
+		pinned:   newLockFile(),
+		resolved: newLockFile(),
+	}
+}
+
+func newLockFile() *LockFile {
+	return &LockFile{
+		Version:   LockFileVersion,
+		Templates: map[string]*LockFileTemplate{},
+	}
+}
+
+func readLockFile(path string) (*LockFile, error) {
+	content, err := os.ReadFile(path) // #nosec G304 lock file is set by user
+	if err != nil {
+		return nil, fmt.Errorf(`unable to read lock file "%v": %w`, path, err)
+	}
+
+	file := &LockFile{}
+	if err := json.Unmarshal(content, file); err != nil {
+		return nil, fmt.Errorf(`unable to parse lock file "%v": %w`, path, err)
+	}
+
+	if file.Version != LockFileVersion {
+		return nil, fmt.Errorf(`unsupported lock file version "%v" in "%v", expected version %v`, file.Version, path, LockFileVersion)
+	}
+
+	if file.Templates == nil {
+		file.Templates = map[string]*LockFileTemplate{}
+	}
+
+	return file, nil
+}
+
+// LoadLockFile loads the lock file and enables locked mode,
+// KeyVault secrets are fetched with their pinned versions and AppConfig settings must match their pinned ETags
+func LoadLockFile(path string) error {
+	file, err := readLockFile(path)
+	if err != nil {
+		return err
+	}
+
+	lockFile.lock.Lock()
+	defer lockFile.lock.Unlock()
+	lockFile.pinned = file
+	lockFile.locked = true
+
+	return nil
+}
+
+// UpdateLockFile writes the entries used by the processed templates to the lock file and returns the changes,
+// entries of templates which were not processed are kept
+func UpdateLockFile(path string) ([]LockFileChange, error) {
+	file, err := readLockFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		file = newLockFile()
+	} else if err != nil {
+		return nil, err
+	}
+
+	lockFile.lock.RLock()
+	defer lockFile.lock.RUnlock()
+
+	changes := []LockFileChange{}
+	for templateName, resolved := range lockFile.resolved.Templates {
+		current := file.Templates[templateName]
+		if current == nil {
+			current = &LockFileTemplate{}
+		}
+
+		changes = append(changes, diffLockEntries(templateName, LockEntryKeyVaultSecret, current.KeyVaultSecrets, resolved.KeyVaultSecrets)...)
+		changes = append(changes, diffLockEntries(templateName, LockEntryAppConfigSetting, current.AppConfigSettings, resolved.AppConfigSettings)...)
+
+		if len(resolved.KeyVaultSecrets) == 0 && len(resolved.AppConfigSettings) == 0 {
+			delete(file.Templates, templateName)
+		} else {
+			file.Templates[templateName] = resolved
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Template != changes[j].Template {
+			return changes[i].Template < changes[j].Template
+		}
+		if changes[i].Type != changes[j].Type {
+			return changes[i].Type < changes[j].Type
+		}
+		return changes[i].Id < changes[j].Id
+	})
+
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf(`unable to marshal lock file: %w`, err)
+	}
+
+	if err := os.WriteFile(path, append(content, '\n'), 0600); err != nil {
+		return nil, fmt.Errorf(`unable to write lock file "%v": %w`, path, err)
+	}
+
+	return changes, nil
+}
+
+func diffLockEntries(templateName, entryType string, current, resolved map[string]string) []LockFileChange {
+	ret := []LockFileChange{}
+	for id, value := range resolved {
+		oldValue, exists := current[id]
+		switch {
+		case !exists:
+			ret = append(ret, LockFileChange{Template: templateName, Type: entryType, Id: id, Status: LockChangeAdded, New: value})
+		case oldValue != value:
+			ret = append(ret, LockFileChange{Template: templateName, Type: entryType, Id: id, Status: LockChangeChanged, Old: oldValue, New: value})
+		}
+	}
+
+	for id, value := range current {
+		if _, exists := resolved[id]; !exists {
+			ret = append(ret, LockFileChange{Template: templateName, Type: entryType, Id: id, Status: LockChangeRemoved, Old: value})
+		}
+	}
+	return ret
+}
+
+// isLocked returns true if versions are taken from the lock file
+func (s *lockStore) isLocked() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.locked
+}
+
+// startTemplate registers template, templates without entries are removed from the lock file
+func (s *lockStore) startTemplate(templateName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.resolved.Templates[templateName] = &LockFileTemplate{}
+}
+
+// pinnedEntry returns the pinned version (or ETag) of id
+func (s *lockStore) pinnedEntry(templateName, entryType, id string) (string, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if template, exists := s.pinned.Templates[templateName]; exists {
+		value, exists := template.entries(entryType)[id]
+		return value, exists
+	}
+	return "", false
+}
+
+// resolveEntry records the used version (or ETag) of id
+func (s *lockStore) resolveEntry(templateName, entryType, id, value string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	template, exists := s.resolved.Templates[templateName]
+	if !exists {
+		template = &LockFileTemplate{}
+		s.resolved.Templates[templateName] = template
+	}
+
+	switch entryType {
+	case LockEntryKeyVaultSecret:
+		if template.KeyVaultSecrets == nil {
+			template.KeyVaultSecrets = map[string]string{}
+		}
+		template.KeyVaultSecrets[id] = value
+	case LockEntryAppConfigSetting:
+		if template.AppConfigSettings == nil {
+			template.AppConfigSettings = map[string]string{}
+		}
+		template.AppConfigSettings[id] = value
+	}
+}
+
+func (t *LockFileTemplate) entries(entryType string) map[string]string {
+	switch entryType {
+	case LockEntryKeyVaultSecret:
+		return t.KeyVaultSecrets
+	case LockEntryAppConfigSetting:
+		return t.AppConfigSettings
+	}
+	return nil
+}
+
+func keyVaultSecretLockId(vaultUrl, secretName string) string {
+	return fmt.Sprintf(`%v/secrets/%v`, vaultUrl, secretName)
+}
+
+func appConfigSettingLockId(appConfigUrl, settingName, label string) string {
+	if label != "" {
+		return fmt.Sprintf(`%v/kv/%v?label=%v`, appConfigUrl, settingName, label)
+	}
+	return fmt.Sprintf(`%v/kv/%v`, appConfigUrl, settingName)
+}
+
+// lockTemplateName is the name of the current template inside the lock file
+func (e *AzureTemplateExecutor) lockTemplateName() string {
+	return e.displayPath(e.currentPath)
+}
+
+// lockedKeyVaultSecretVersion returns the pinned version of secret in locked mode
+func (e *AzureTemplateExecutor) lockedKeyVaultSecretVersion(vaultUrl, secretName string) (string, error) {
+	version, exists := e.lockFile.pinnedEntry(e.lockTemplateName(), LockEntryKeyVaultSecret, keyVaultSecretLockId(vaultUrl, secretName))
+	if !exists {
+		return "", fmt.Errorf(`secret "%[2]v" from vault "%[1]v" is not pinned in lock file for template "%[3]v", please run update-lock`, vaultUrl, secretName, e.lockTemplateName())
+	}
+	return version, nil
+}
+
+// checkAppConfigSettingLock checks the ETag of setting (result of azAppConfigSetting) in locked mode and records the used ETag
+func (e *AzureTemplateExecutor) checkAppConfigSettingLock(appConfigUrl, settingName, label string, val interface{}) error {
+	etag := ""
+	if setting, ok := val.(map[string]interface{}); ok {
+		etag, _ = setting["eTag"].(string)
+	}
+
+	id := appConfigSettingLockId(appConfigUrl, settingName, label)
+	if e.lockFile.isLocked() {
+		pinnedEtag, exists := e.lockFile.pinnedEntry(e.lockTemplateName(), LockEntryAppConfigSetting, id)
+		if !exists {
+			return fmt.Errorf(`app setting "%[2]v" from appconfig instance "%[1]v" is not pinned in lock file for template "%[3]v", please run update-lock`, appConfigUrl, settingName, e.lockTemplateName())
+		}
+
+		if pinnedEtag != etag {
+			return fmt.Errorf(`app setting "%[2]v" from appconfig instance "%[1]v" has changed since lock file was updated (ETag "%[3]v", pinned "%[4]v"), please run update-lock`, appConfigUrl, settingName, etag, pinnedEtag)
+		}
+	}
+
+	e.lockFile.resolveEntry(e.lockTemplateName(), LockEntryAppConfigSetting, id, etag)
+
+	// referenced KeyVault secrets are resolved inside the cached result, so they need to be checked and recorded for every template
+	if setting, ok := val.(map[string]interface{}); ok {
+		if err := e.checkAppConfigKeyVaultReferenceLock(setting["keyVaultReference"]); err != nil {
+			return fmt.Errorf(`unable to fetch keyvault reference from app setting value "%[2]v" from appconfig instance "%[1]v": %[3]w`, appConfigUrl, settingName, err)
+		}
+	}
+
+	return nil
+}
+
+// checkAppConfigKeyVaultReferenceLock checks the version of a referenced KeyVault secret (without version inside the uri)
+// in locked mode and records the used version
+func (e *AzureTemplateExecutor) checkAppConfigKeyVaultReferenceLock(val interface{}) error {
+	data, ok := val.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	uri, _ := data["uri"].(string)
+	reference, err := parseAppConfigKeyVaultReference(uri)
+	if err != nil || reference.Version != "" {
+		// invalid references are already reported, explicit versions are not pinned
+		return nil
+	}
+
+	version, _ := data["version"].(string)
+	if e.lockFile.isLocked() {
+		pinnedVersion, err := e.lockedKeyVaultSecretVersion(reference.VaultUrl, reference.SecretName)
+		if err != nil {
+			return err
+		}
+
+		if pinnedVersion != version {
+			return fmt.Errorf(`secret "%[2]v" from vault "%[1]v" uses version "%[3]v", pinned "%[4]v" in lock file for template "%[5]v", please run update-lock`, reference.VaultUrl, reference.SecretName, version, pinnedVersion, e.lockTemplateName())
+		}
+	}
+
+	e.lockFile.resolveEntry(e.lockTemplateName(), LockEntryKeyVaultSecret, keyVaultSecretLockId(reference.VaultUrl, reference.SecretName), version)
+	return nil
+}
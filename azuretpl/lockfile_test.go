@@ -0,0 +1,120 @@
+package azuretpl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffLockEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  map[string]string
+		resolved map[string]string
+		expected []string
+	}{
+		{name: "unchanged", current: map[string]string{"a": "1"}, resolved: map[string]string{"a": "1"}, expected: []string{}},
+		{name: "added", current: nil, resolved: map[string]string{"a": "1"}, expected: []string{"added:a::1"}},
+		{name: "changed", current: map[string]string{"a": "1"}, resolved: map[string]string{"a": "2"}, expected: []string{"changed:a:1:2"}},
+		{name: "removed", current: map[string]string{"a": "1"}, resolved: map[string]string{}, expected: []string{"removed:a:1:"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := []string{}
+			for _, change := range diffLockEntries("test.tpl", LockEntryKeyVaultSecret, test.current, test.resolved) {
+				result = append(result, change.Status+":"+change.Id+":"+change.Old+":"+change.New)
+			}
+
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestUpdateAndLoadLockFile(t *testing.T) {
+	previousLockFile := lockFile
+	defer func() { lockFile = previousLockFile }()
+
+	path := filepath.Join(t.TempDir(), "azuretpl.lock")
+	content := `{
+  "version": 1,
+  "templates": {
+    "a.tpl": {"keyVaultSecrets": {"https://v.vault.azure.net/secrets/old": "1", "https://v.vault.azure.net/secrets/s": "1"}},
+    "b.tpl": {"appConfigSettings": {"https://c.azconfig.io/kv/key": "etag"}},
+    "c.tpl": {"keyVaultSecrets": {"https://v.vault.azure.net/secrets/s": "1"}}
+  }
+}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// a.tpl is changed, b.tpl has no entries anymore and c.tpl is not processed
+	lockFile = newLockStore()
+	lockFile.startTemplate("a.tpl")
+	lockFile.resolveEntry("a.tpl", LockEntryKeyVaultSecret, "https://v.vault.azure.net/secrets/s", "2")
+	lockFile.resolveEntry("a.tpl", LockEntryAppConfigSetting, "https://c.azconfig.io/kv/key?label=prod", "etag")
+	lockFile.startTemplate("b.tpl")
+
+	changes, err := UpdateLockFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := []string{}
+	for _, change := range changes {
+		result = append(result, change.Template+":"+change.Type+":"+change.Status+":"+change.Id)
+	}
+	expected := []string{
+		"a.tpl:appConfigSetting:added:https://c.azconfig.io/kv/key?label=prod",
+		"a.tpl:keyVaultSecret:removed:https://v.vault.azure.net/secrets/old",
+		"a.tpl:keyVaultSecret:changed:https://v.vault.azure.net/secrets/s",
+		"b.tpl:appConfigSetting:removed:https://c.azconfig.io/kv/key",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected changes %v, got %v", expected, result)
+	}
+
+	// written lock file is used in locked mode
+	lockFile = newLockStore()
+	if err := LoadLockFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !lockFile.isLocked() {
+		t.Errorf("expected locked mode")
+	}
+
+	pinned := []struct {
+		template  string
+		entryType string
+		id        string
+		value     string
+		exists    bool
+	}{
+		{template: "a.tpl", entryType: LockEntryKeyVaultSecret, id: "https://v.vault.azure.net/secrets/s", value: "2", exists: true},
+		{template: "a.tpl", entryType: LockEntryKeyVaultSecret, id: "https://v.vault.azure.net/secrets/old", exists: false},
+		{template: "a.tpl", entryType: LockEntryAppConfigSetting, id: "https://c.azconfig.io/kv/key?label=prod", value: "etag", exists: true},
+		{template: "b.tpl", entryType: LockEntryAppConfigSetting, id: "https://c.azconfig.io/kv/key", exists: false},
+		{template: "c.tpl", entryType: LockEntryKeyVaultSecret, id: "https://v.vault.azure.net/secrets/s", value: "1", exists: true},
+	}
+	for _, entry := range pinned {
+		value, exists := lockFile.pinnedEntry(entry.template, entry.entryType, entry.id)
+		if value != entry.value || exists != entry.exists {
+			t.Errorf("expected pinned entry %v %v to be %q (%v), got %q (%v)", entry.template, entry.id, entry.value, entry.exists, value, exists)
+		}
+	}
+}
+
+func TestReadLockFileVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "azuretpl.lock")
+	if err := os.WriteFile(path, []byte(`{"version": 2, "templates": {}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readLockFile(path); err == nil {
+		t.Errorf("expected error for unsupported version")
+	}
+}
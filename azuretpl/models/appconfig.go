@@ -41,6 +41,35 @@ type (
 
 		// Sync token for the Azure App Configuration client, corresponding to the current state of the client.
 		SyncToken *string `json:"syncToken"`
+
+		// The Azure KeyVault secret referenced by the configuration setting (only set for KeyVault references).
+		KeyVaultReference *AzAppconfigKeyVaultReference `json:"keyVaultReference,omitempty"`
+	}
+
+	AzAppconfigKeyVaultReference struct {
+		// The uri of the referenced secret (as stored inside the configuration setting).
+		Uri string `json:"uri"`
+
+		// The vault url of the referenced secret.
+		VaultUrl string `json:"vaultUrl"`
+
+		// The name of the referenced secret.
+		SecretName string `json:"secretName"`
+
+		// The used version of the referenced secret.
+		Version string `json:"version"`
+
+		// True if the version is set inside the uri (otherwise the latest or pinned version is used).
+		VersionPinned bool `json:"versionPinned"`
+
+		// The content type of the referenced secret.
+		ContentType string `json:"contentType"`
+
+		// The creation date of the referenced secret version.
+		Created *time.Time `json:"created"`
+
+		// The expiry date of the referenced secret version.
+		Expires *time.Time `json:"expires"`
 	}
 )
 
@@ -62,3 +91,70 @@ func NewAzAppconfigSettingFromReponse(setting azappconfig.GetSettingResponse) *A
 	ret.SyncToken = to.StringPtr(string(setting.SyncToken))
 	return ret
 }
+
+type (
+	AzAppconfigFeatureFlag struct {
+		// The id (name) of the feature flag.
+		Id string `json:"id"`
+
+		// The description of the feature flag.
+		Description string `json:"description"`
+
+		// The display name of the feature flag.
+		DisplayName string `json:"displayName,omitempty"`
+
+		// Indicates whether the feature flag is turned on.
+		Enabled bool `json:"enabled"`
+
+		// Result of the evaluation of enabled and client filters (targeting and time window) for the evaluation context.
+		Active bool `json:"active"`
+
+		// The conditions (client filters) of the feature flag.
+		Conditions AzAppconfigFeatureFlagConditions `json:"conditions"`
+
+		// The variants of the feature flag.
+		Variants []AzAppconfigFeatureFlagVariant `json:"variants"`
+
+		// The allocation of variants (as stored inside the feature flag).
+		Allocation map[string]interface{} `json:"allocation,omitempty"`
+
+		// The key of the configuration setting (.appconfig.featureflag/<id>).
+		Key *string `json:"key"`
+
+		// The label of the configuration setting.
+		Label *string `json:"label"`
+
+		// An ETag indicating the state of the configuration setting.
+		ETag *azcore.ETag `json:"eTag"`
+
+		// The last time a modifying operation was performed on the configuration setting.
+		LastModified *time.Time `json:"lastModified"`
+	}
+
+	AzAppconfigFeatureFlagConditions struct {
+		// Any (one client filter must be satisfied) or All (all client filters must be satisfied).
+		RequirementType string `json:"requirementType"`
+
+		// The client filters (eg. Microsoft.Targeting or Microsoft.TimeWindow).
+		ClientFilters []AzAppconfigFeatureFlagFilter `json:"clientFilters"`
+	}
+
+	AzAppconfigFeatureFlagFilter struct {
+		// The name of the client filter.
+		Name string `json:"name"`
+
+		// The parameters of the client filter.
+		Parameters map[string]interface{} `json:"parameters"`
+	}
+
+	AzAppconfigFeatureFlagVariant struct {
+		// The name of the variant.
+		Name string `json:"name"`
+
+		// The configuration value of the variant.
+		ConfigurationValue interface{} `json:"configurationValue"`
+
+		// Overrides the enabled state of the feature flag if the variant is assigned (None, Enabled or Disabled).
+		StatusOverride string `json:"statusOverride,omitempty"`
+	}
+)
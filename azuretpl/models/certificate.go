@@ -0,0 +1,28 @@
+package models
+
+type (
+	// Certificate is a decoded certificate bundle (eg. from PKCS#12 or PEM secrets)
+	Certificate struct {
+		Subject      string   `json:"subject"`
+		Issuer       string   `json:"issuer"`
+		SerialNumber string   `json:"serialNumber"`
+		DNSNames     []string `json:"dnsNames"`
+
+		// Validity of the certificate (unix timestamps).
+		NotBefore int64 `json:"notBefore"`
+		NotAfter  int64 `json:"notAfter"`
+
+		// SHA-1 thumbprint of the certificate (uppercase hex).
+		Thumbprint string `json:"thumbprint"`
+
+		// The leaf certificate (PEM).
+		Certificate string `json:"certificate"`
+
+		// The intermediate certificates (PEM).
+		Chain string `json:"chain"`
+
+		// Leaf certificate with chain and private key (PEM), tls.key is empty if the bundle has no private key.
+		TlsCrt string `json:"tls.crt"`
+		TlsKey string `json:"tls.key"`
+	}
+)
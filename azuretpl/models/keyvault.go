@@ -1,6 +1,11 @@
 package models
 
 import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azcertificates"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	"github.com/webdevops/go-common/utils/to"
 )
@@ -27,6 +32,72 @@ type (
 		Version string `json:"version" yaml:"version"`
 		Name    string `json:"name" yaml:"name"`
 	}
+
+	AzCertificate struct {
+		// The certificate management attributes.
+		Attributes *azcertificates.CertificateAttributes `json:"attributes"`
+
+		// The content type of the backing secret (application/x-pkcs12 or application/x-pem-file).
+		ContentType *string `json:"contentType,omitempty"`
+
+		// The certificate id.
+		ID string `json:"id"`
+
+		// The id of the backing secret (containing certificate and private key).
+		SecretID string `json:"secretId,omitempty"`
+
+		// The id of the backing key.
+		KeyID string `json:"keyId,omitempty"`
+
+		// Application specific metadata in the form of key-value pairs.
+		Tags map[string]*string `json:"tags"`
+
+		// SHA-1 thumbprint of the certificate (uppercase hex).
+		Thumbprint string `json:"thumbprint"`
+
+		Subject      string   `json:"subject,omitempty"`
+		Issuer       string   `json:"issuer,omitempty"`
+		SerialNumber string   `json:"serialNumber,omitempty"`
+		DNSNames     []string `json:"dnsNames,omitempty"`
+
+		// The certificate (PEM).
+		Certificate string `json:"certificate,omitempty"`
+
+		// The intermediate certificates (PEM, only available with private key).
+		Chain string `json:"chain,omitempty"`
+
+		// Certificate with chain and private key (PEM, only available with private key).
+		TlsCrt string `json:"tls.crt,omitempty"`
+		TlsKey string `json:"tls.key,omitempty"`
+
+		Version string `json:"version" yaml:"version"`
+		Name    string `json:"name" yaml:"name"`
+	}
+
+	AzKey struct {
+		// The key management attributes.
+		Attributes *azkeys.KeyAttributes `json:"attributes"`
+
+		// The key id.
+		ID string `json:"id"`
+
+		// The public key as JSON web key.
+		JWK *azkeys.JSONWebKey `json:"jwk"`
+
+		// The key type (eg. RSA, RSA-HSM, EC, EC-HSM).
+		KeyType string `json:"keyType"`
+
+		// The public key (PEM, empty for symmetric keys).
+		PublicKey string `json:"publicKey,omitempty"`
+
+		// Application specific metadata in the form of key-value pairs.
+		Tags map[string]*string `json:"tags"`
+
+		Managed bool `json:"managed"`
+
+		Version string `json:"version" yaml:"version"`
+		Name    string `json:"name" yaml:"name"`
+	}
 )
 
 func NewAzSecretItem(secret azsecrets.Secret) *AzSecret {
@@ -54,3 +125,53 @@ func NewAzSecretItemFromSecretproperties(secret azsecrets.SecretProperties) *AzS
 		Name:        secret.ID.Name(),
 	}
 }
+
+func NewAzCertificateItem(certificate azcertificates.Certificate) *AzCertificate {
+	ret := &AzCertificate{
+		Attributes:  certificate.Attributes,
+		ContentType: certificate.ContentType,
+		ID:          string(*certificate.ID),
+		Tags:        certificate.Tags,
+		Thumbprint:  strings.ToUpper(hex.EncodeToString(certificate.X509Thumbprint)),
+		Version:     certificate.ID.Version(),
+		Name:        certificate.ID.Name(),
+	}
+
+	if ret.ContentType == nil && certificate.Policy != nil && certificate.Policy.SecretProperties != nil {
+		ret.ContentType = certificate.Policy.SecretProperties.ContentType
+	}
+
+	if certificate.SID != nil {
+		ret.SecretID = string(*certificate.SID)
+	}
+
+	if certificate.KID != nil {
+		ret.KeyID = string(*certificate.KID)
+	}
+
+	return ret
+}
+
+func NewAzCertificateItemFromCertificateProperties(certificate azcertificates.CertificateProperties) *AzCertificate {
+	return &AzCertificate{
+		Attributes: certificate.Attributes,
+		ID:         string(*certificate.ID),
+		Tags:       certificate.Tags,
+		Thumbprint: strings.ToUpper(hex.EncodeToString(certificate.X509Thumbprint)),
+		Version:    certificate.ID.Version(),
+		Name:       certificate.ID.Name(),
+	}
+}
+
+func NewAzKeyItem(key azkeys.KeyBundle) *AzKey {
+	return &AzKey{
+		Attributes: key.Attributes,
+		ID:         string(*key.Key.KID),
+		JWK:        key.Key,
+		KeyType:    to.String((*string)(key.Key.Kty)),
+		Tags:       key.Tags,
+		Managed:    to.Bool(key.Managed),
+		Version:    key.Key.KID.Version(),
+		Name:       key.Key.KID.Name(),
+	}
+}
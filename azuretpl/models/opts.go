@@ -6,9 +6,44 @@ import (
 
 type (
 	Opts struct {
+		Auth struct {
+			Method   string `long:"auth.method"  env:"AZURETPL_AUTH_METHOD"  description:"Azure authentication method (credentials are read from the standard AZURE_* env vars)" choice:"azcli" choice:"default" choice:"environment" choice:"workloadidentity" choice:"managedidentity" choice:"clientsecret" choice:"clientcertificate" choice:"oidc" default:"azcli"` // nolint:staticcheck // multiple choices are ok
+			Profiles string `long:"auth.profiles" env:"AZURETPL_AUTH_PROFILES" description:"path to yaml/json file with named credential profiles (used by azWithProfile)"`
+		}
+
+		Cloud struct {
+			ConfigFile string `long:"cloud.config" env:"AZURETPL_CLOUD_CONFIG" description:"path to json file with endpoints of a custom Azure cloud (eg. Azure Stack), overrides endpoints of known clouds"`
+		}
+
 		Keyvault struct {
-			ExpiryWarning time.Duration `long:"keyvault.expiry.warningduration"   env:"AZURETPL_KEYVAULT_EXPIRY_WARNING_DURATION"   description:"warn before soon expiring Azure KeyVault entries" default:"168h"`
-			IgnoreExpiry  bool          `long:"keyvault.expiry.ignore"            env:"AZURETPL_KEYVAULT_EXPIRY_IGNORE"   description:"ignore expiry date of Azure KeyVault entries and don't fail'"`
+			ExpiryWarning         time.Duration `long:"keyvault.expiry.warningduration"   env:"AZURETPL_KEYVAULT_EXPIRY_WARNING_DURATION"   description:"warn before soon expiring Azure KeyVault entries" default:"168h"`
+			IgnoreExpiry          bool          `long:"keyvault.expiry.ignore"            env:"AZURETPL_KEYVAULT_EXPIRY_IGNORE"   description:"ignore expiry date of Azure KeyVault entries and don't fail'"`
+			Concurrency           int           `long:"keyvault.concurrency"              env:"AZURETPL_KEYVAULT_CONCURRENCY"     description:"number of concurrent calls when fetching multiple Azure KeyVault secrets (eg. azKeyVaultSecretValues)" default:"10"`
+			Policy                string        `long:"keyvault.policy"                   env:"AZURETPL_KEYVAULT_POLICY"          description:"path to yaml/json file with expiry policy for Azure KeyVault secrets (expiry, required expiry date, max age)"`
+			ReferenceAllowedHosts []string      `long:"keyvault.reference.allowed-host"   env:"AZURETPL_KEYVAULT_REFERENCE_ALLOWED_HOSTS" env-delim:"," description:"allowed vault hosts of KeyVault references inside AppConfig settings (eg. myvault.vault.azure.net or *.vault.azure.net), default: all vaults of the current Azure cloud"`
+		}
+
+		Fixture struct {
+			Record        string `long:"record"                 env:"AZURETPL_RECORD"                 description:"record results of all Azure template functions into this fixture file (json)"`
+			Replay        string `long:"replay"                 env:"AZURETPL_REPLAY"                 description:"replay results of all Azure template functions from this fixture file (json), no Azure API calls are made"`
+			RedactSecrets bool   `long:"record.redact-secrets"  env:"AZURETPL_RECORD_REDACT_SECRETS"  description:"redact secret values (eg. Azure KeyVault secrets and access keys) when recording fixtures"`
+		}
+
+		Lock struct {
+			Path   string `long:"lock.file"  env:"AZURETPL_LOCK_FILE"  description:"path to lock file with pinned versions of Azure KeyVault secrets and ETags of AppConfig settings per template (written by update-lock)" default:"azuretpl.lock"`
+			Locked bool   `long:"locked"     env:"AZURETPL_LOCKED"     description:"use pinned versions of Azure KeyVault secrets from lock file and fail if secrets are not pinned or AppConfig settings have changed"`
+		}
+
+		Cache struct {
+			Disabled      bool                     `long:"no-cache"            env:"AZURETPL_NO_CACHE"                         description:"disable caching of template function results, every call is fetched from Azure"`
+			Dir           string                   `long:"cache.dir"           env:"AZURETPL_CACHE_DIR"                        description:"directory for persistent cache of template function results (disabled if empty)"`
+			Ttl           time.Duration            `long:"cache.ttl"           env:"AZURETPL_CACHE_TTL"                        description:"TTL for cached template function results" default:"15m"`
+			FunctionTtl   map[string]time.Duration `long:"cache.ttl.function"  env:"AZURETPL_CACHE_TTL_FUNCTION" env-delim:","  description:"TTL for cached template function results per template function (eg. azResourceGraphQuery:1m)"`
+			EncryptionKey string                   `json:"-"                   env:"AZURETPL_CACHE_ENCRYPTION_KEY"             description:"key for encrypting secret results (eg. KeyVault secrets) in persistent cache, secret results are not cached persistently if empty"`
+		}
+
+		Prefetch struct {
+			Concurrency int `long:"prefetch.concurrency"  env:"AZURETPL_PREFETCH_CONCURRENCY"  description:"number of concurrent calls for prefetching template functions with constant arguments outside of if/range/with blocks and and/or/default arguments (0 = disabled)" default:"10"`
 		}
 
 		ValuesFiles  []string `long:"values"  env:"AZURETPL_VALUES" env-delim:":" description:"path to yaml files for .Values"`
@@ -3,11 +3,6 @@ package azuretpl
 import (
 	"fmt"
 	"log/slog"
-
-	msgraphcore "github.com/microsoftgraph/msgraph-sdk-go-core"
-	"github.com/microsoftgraph/msgraph-sdk-go/applications"
-	"github.com/microsoftgraph/msgraph-sdk-go/models"
-	"github.com/webdevops/go-common/utils/to"
 )
 
 // mgApplicationByDisplayName fetches one application from MsGraph API using displayName
@@ -20,18 +15,8 @@ func (e *AzureTemplateExecutor) mgApplicationByDisplayName(displayName string) (
 
 	cacheKey := generateCacheKey(`mgApplicationByDisplayName`, displayName)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		requestOpts := &applications.ApplicationsRequestBuilderGetRequestConfiguration{
-			QueryParameters: &applications.ApplicationsRequestBuilderGetQueryParameters{
-				Filter: to.StringPtr(fmt.Sprintf(`displayName eq '%v'`,
-					escapeMsGraphFilter(displayName))),
-			},
-		}
-		result, err := e.msGraphClient().ServiceClient().Applications().Get(e.ctx, requestOpts)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to query MsGraph application: %w`, err)
-		}
-
-		list, err := e.mgApplicationCreateListFromResult(result)
+		filter := fmt.Sprintf(`displayName eq '%v'`, escapeMsGraphFilter(displayName))
+		list, err := e.providers.MsGraph.ListApplications(e.ctx, filter)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to query MsGraph application: %w`, err)
 		}
@@ -57,12 +42,7 @@ func (e *AzureTemplateExecutor) mgApplicationList(filter string) (interface{}, e
 
 	cacheKey := generateCacheKey(`mgApplicationList`, filter)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		result, err := e.msGraphClient().ServiceClient().Applications().Get(e.ctx, nil)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to query MsGraph applications: %w`, err)
-		}
-
-		list, err := e.mgApplicationCreateListFromResult(result)
+		list, err := e.providers.MsGraph.ListApplications(e.ctx, filter)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to query MsGraph applications: %w`, err)
 		}
@@ -70,26 +50,3 @@ func (e *AzureTemplateExecutor) mgApplicationList(filter string) (interface{}, e
 		return list, nil
 	})
 }
-
-func (e *AzureTemplateExecutor) mgApplicationCreateListFromResult(result models.ApplicationCollectionResponseable) (list []interface{}, err error) {
-	pageIterator, pageIteratorErr := msgraphcore.NewPageIterator[models.Applicationable](result, e.msGraphClient().RequestAdapter(), models.CreateApplicationCollectionResponseFromDiscriminatorValue)
-	if pageIteratorErr != nil {
-		return list, pageIteratorErr
-	}
-
-	iterateErr := pageIterator.Iterate(e.ctx, func(application models.Applicationable) bool {
-		obj, serializeErr := e.mgSerializeObject(application)
-		if serializeErr != nil {
-			err = serializeErr
-			return false
-		}
-
-		list = append(list, obj)
-		return true
-	})
-	if iterateErr != nil {
-		return list, iterateErr
-	}
-
-	return
-}
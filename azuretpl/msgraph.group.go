@@ -3,11 +3,6 @@ package azuretpl
 import (
 	"fmt"
 	"log/slog"
-
-	msgraphcore "github.com/microsoftgraph/msgraph-sdk-go-core"
-	"github.com/microsoftgraph/msgraph-sdk-go/groups"
-	"github.com/microsoftgraph/msgraph-sdk-go/models"
-	"github.com/webdevops/go-common/utils/to"
 )
 
 // mgGroupByDisplayName fetches one group from MsGraph API using displayName
@@ -17,20 +12,11 @@ func (e *AzureTemplateExecutor) mgGroupByDisplayName(displayName string) (interf
 	if val, enabled := e.lintResult(); enabled {
 		return val, nil
 	}
+
 	cacheKey := generateCacheKey(`mgGroupByDisplayName`, displayName)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		requestOpts := &groups.GroupsRequestBuilderGetRequestConfiguration{
-			QueryParameters: &groups.GroupsRequestBuilderGetQueryParameters{
-				Filter: to.StringPtr(fmt.Sprintf(`displayName eq '%v'`,
-					escapeMsGraphFilter(displayName))),
-			},
-		}
-		result, err := e.msGraphClient().ServiceClient().Groups().Get(e.ctx, requestOpts)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to query MsGraph group: %w`, err)
-		}
-
-		list, err := e.mgGroupCreateListFromResult(result)
+		filter := fmt.Sprintf(`displayName eq '%v'`, escapeMsGraphFilter(displayName))
+		list, err := e.providers.MsGraph.ListGroups(e.ctx, filter)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to query MsGraph group: %w`, err)
 		}
@@ -53,42 +39,14 @@ func (e *AzureTemplateExecutor) mgGroupList(filter string) (interface{}, error)
 	if val, enabled := e.lintResult(); enabled {
 		return val, nil
 	}
+
 	cacheKey := generateCacheKey(`mgGroupList`, filter)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		result, err := e.msGraphClient().ServiceClient().Groups().Get(e.ctx, nil)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to query MsGraph group: %w`, err)
-		}
-
-		list, err := e.mgGroupCreateListFromResult(result)
+		list, err := e.providers.MsGraph.ListGroups(e.ctx, filter)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to query MsGraph groups: %w`, err)
 		}
 
 		return list, nil
 	})
-
-}
-
-func (e *AzureTemplateExecutor) mgGroupCreateListFromResult(result models.GroupCollectionResponseable) (list []interface{}, err error) {
-	pageIterator, pageIteratorErr := msgraphcore.NewPageIterator[models.Groupable](result, e.msGraphClient().RequestAdapter(), models.CreateGroupCollectionResponseFromDiscriminatorValue)
-	if pageIteratorErr != nil {
-		return list, pageIteratorErr
-	}
-
-	iterateErr := pageIterator.Iterate(e.ctx, func(group models.Groupable) bool {
-		obj, serializeErr := e.mgSerializeObject(group)
-		if serializeErr != nil {
-			err = serializeErr
-			return false
-		}
-
-		list = append(list, obj)
-		return true
-	})
-	if iterateErr != nil {
-		return list, iterateErr
-	}
-
-	return
 }
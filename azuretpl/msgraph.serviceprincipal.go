@@ -3,11 +3,6 @@ package azuretpl
 import (
 	"fmt"
 	"log/slog"
-
-	msgraphcore "github.com/microsoftgraph/msgraph-sdk-go-core"
-	"github.com/microsoftgraph/msgraph-sdk-go/models"
-	"github.com/microsoftgraph/msgraph-sdk-go/serviceprincipals"
-	"github.com/webdevops/go-common/utils/to"
 )
 
 // mgServicePrincipalByDisplayName fetches one servicePrincipal from MsGraph API using displayName
@@ -20,18 +15,8 @@ func (e *AzureTemplateExecutor) mgServicePrincipalByDisplayName(displayName stri
 
 	cacheKey := generateCacheKey(`mgServicePrincipalByDisplayName`, displayName)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		requestOpts := &serviceprincipals.ServicePrincipalsRequestBuilderGetRequestConfiguration{
-			QueryParameters: &serviceprincipals.ServicePrincipalsRequestBuilderGetQueryParameters{
-				Filter: to.StringPtr(fmt.Sprintf(`displayName eq '%v'`,
-					escapeMsGraphFilter(displayName))),
-			},
-		}
-		result, err := e.msGraphClient().ServiceClient().ServicePrincipals().Get(e.ctx, requestOpts)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to query MsGraph servicePrincipal: %w`, err)
-		}
-
-		list, err := e.mgServicePrincipalCreateListFromResult(result)
+		filter := fmt.Sprintf(`displayName eq '%v'`, escapeMsGraphFilter(displayName))
+		list, err := e.providers.MsGraph.ListServicePrincipals(e.ctx, filter)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to query MsGraph servicePrincipal: %w`, err)
 		}
@@ -57,39 +42,11 @@ func (e *AzureTemplateExecutor) mgServicePrincipalList(filter string) (interface
 
 	cacheKey := generateCacheKey(`mgServicePrincipalList`, filter)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		result, err := e.msGraphClient().ServiceClient().ServicePrincipals().Get(e.ctx, nil)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to query MsGraph servicePrincipal: %w`, err)
-		}
-
-		list, err := e.mgServicePrincipalCreateListFromResult(result)
+		list, err := e.providers.MsGraph.ListServicePrincipals(e.ctx, filter)
 		if err != nil {
-			return nil, fmt.Errorf(`failed to query MsGraph servicePrincipal: %w`, err)
+			return nil, fmt.Errorf(`failed to query MsGraph servicePrincipals: %w`, err)
 		}
 
 		return list, nil
 	})
 }
-
-func (e *AzureTemplateExecutor) mgServicePrincipalCreateListFromResult(result models.ServicePrincipalCollectionResponseable) (list []interface{}, err error) {
-	pageIterator, pageIteratorErr := msgraphcore.NewPageIterator[models.ServicePrincipalable](result, e.msGraphClient().RequestAdapter(), models.CreateServicePrincipalCollectionResponseFromDiscriminatorValue)
-	if pageIteratorErr != nil {
-		return list, pageIteratorErr
-	}
-
-	iterateErr := pageIterator.Iterate(e.ctx, func(servicePrincipal models.ServicePrincipalable) bool {
-		obj, serializeErr := e.mgSerializeObject(servicePrincipal)
-		if serializeErr != nil {
-			err = serializeErr
-			return false
-		}
-
-		list = append(list, obj)
-		return true
-	})
-	if iterateErr != nil {
-		return list, iterateErr
-	}
-
-	return
-}
@@ -3,11 +3,6 @@ package azuretpl
 import (
 	"fmt"
 	"log/slog"
-
-	msgraphcore "github.com/microsoftgraph/msgraph-sdk-go-core"
-	"github.com/microsoftgraph/msgraph-sdk-go/models"
-	"github.com/microsoftgraph/msgraph-sdk-go/users"
-	"github.com/webdevops/go-common/utils/to"
 )
 
 // mgUserByUserPrincipalName fetches one user from MsGraph API using userPrincipalName
@@ -20,20 +15,8 @@ func (e *AzureTemplateExecutor) mgUserByUserPrincipalName(userPrincipalName stri
 
 	cacheKey := generateCacheKey(`mgUserByUserPrincipalName`, userPrincipalName)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		requestOpts := &users.UsersRequestBuilderGetRequestConfiguration{
-			QueryParameters: &users.UsersRequestBuilderGetQueryParameters{
-				Filter: to.StringPtr(fmt.Sprintf(
-					`userPrincipalName eq '%v'`,
-					escapeMsGraphFilter(userPrincipalName),
-				)),
-			},
-		}
-		result, err := e.msGraphClient().ServiceClient().Users().Get(e.ctx, requestOpts)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to query MsGraph user: %w`, err)
-		}
-
-		list, err := e.mgUserCreateListFromResult(result)
+		filter := fmt.Sprintf(`userPrincipalName eq '%v'`, escapeMsGraphFilter(userPrincipalName))
+		list, err := e.providers.MsGraph.ListUsers(e.ctx, filter)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to query MsGraph user: %w`, err)
 		}
@@ -59,12 +42,7 @@ func (e *AzureTemplateExecutor) mgUserList(filter string) (interface{}, error) {
 
 	cacheKey := generateCacheKey(`mgUserList`, filter)
 	return e.cacheResult(cacheKey, func() (interface{}, error) {
-		result, err := e.msGraphClient().ServiceClient().Users().Get(e.ctx, nil)
-		if err != nil {
-			return nil, fmt.Errorf(`failed to query MsGraph users: %w`, err)
-		}
-
-		list, err := e.mgUserCreateListFromResult(result)
+		list, err := e.providers.MsGraph.ListUsers(e.ctx, filter)
 		if err != nil {
 			return nil, fmt.Errorf(`failed to query MsGraph users: %w`, err)
 		}
@@ -72,26 +50,3 @@ func (e *AzureTemplateExecutor) mgUserList(filter string) (interface{}, error) {
 		return list, nil
 	})
 }
-
-func (e *AzureTemplateExecutor) mgUserCreateListFromResult(result models.UserCollectionResponseable) (list []interface{}, err error) {
-	pageIterator, pageIteratorErr := msgraphcore.NewPageIterator[models.Userable](result, e.msGraphClient().RequestAdapter(), models.CreateUserCollectionResponseFromDiscriminatorValue)
-	if pageIteratorErr != nil {
-		return list, pageIteratorErr
-	}
-
-	iterateErr := pageIterator.Iterate(e.ctx, func(user models.Userable) bool {
-		obj, serializeErr := e.mgSerializeObject(user)
-		if serializeErr != nil {
-			err = serializeErr
-			return false
-		}
-
-		list = append(list, obj)
-		return true
-	})
-	if iterateErr != nil {
-		return list, iterateErr
-	}
-
-	return
-}
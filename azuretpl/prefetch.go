@@ -0,0 +1,108 @@
+package azuretpl
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	textTemplate "text/template"
+)
+
+var (
+	// prefetchFunctions are template functions which are prefetched if all arguments are constants
+	prefetchFunctions = map[string]bool{
+		`azResource`:                            true,
+		`azResourceList`:                        true,
+		`azManagementGroup`:                     true,
+		`azManagementGroupSubscriptionList`:     true,
+		`azSubscription`:                        true,
+		`azSubscriptionList`:                    true,
+		`azPublicIpAddress`:                     true,
+		`azPublicIpPrefixAddressPrefix`:         true,
+		`azVirtualNetworkAddressPrefixes`:       true,
+		`azVirtualNetworkSubnetAddressPrefixes`: true,
+		`azKeyVaultSecret`:                      true,
+		`azKeyVaultSecretVersions`:              true,
+		`azKeyVaultSecretList`:                  true,
+		`azKeyVaultSecretValues`:                true,
+		`azKeyVaultCertificate`:                 true,
+		`azKeyVaultCertificateWithKey`:          true,
+		`azKeyVaultCertificateList`:             true,
+		`azKeyVaultKey`:                         true,
+		`azRedisAccessKeys`:                     true,
+		`azStorageAccountAccessKeys`:            true,
+		`azStorageAccountContainerBlob`:         true,
+		`azEventHubListByNamespace`:             true,
+		`azAppConfigSetting`:                    true,
+		`azAppConfigSettingList`:                true,
+		`azAppConfigFeatureFlag`:                true,
+		`azAppConfigFeatureFlagList`:            true,
+		`azAppConfigSnapshotSetting`:            true,
+		`azAppConfigSnapshotSettingList`:        true,
+		`azManagedClusterUserCredentials`:       true,
+		`azResourceGraphQuery`:                  true,
+		`azRoleDefinition`:                      true,
+		`azRoleDefinitionList`:                  true,
+		`mgUserByUserPrincipalName`:             true,
+		`mgUserList`:                            true,
+		`mgGroupByDisplayName`:                  true,
+		`mgGroupList`:                           true,
+		`mgServicePrincipalByDisplayName`:       true,
+		`mgServicePrincipalList`:                true,
+		`mgApplicationByDisplayName`:            true,
+		`mgApplicationList`:                     true,
+	}
+)
+
+// prefetch walks the parsed template and executes all Azure template function calls with constant arguments
+// concurrently, results are stored in the cache so the template execution doesn't wait for each call sequentially.
+// Only calls which are always executed are prefetched, calls inside if/else/range/with bodies or conditional
+// arguments of and/or/default could be guarded by the template.
+// Prefetching only warms the cache, lock file, summary and policy are recorded when the template is executed.
+func (e *AzureTemplateExecutor) prefetch(tmpl *textTemplate.Template) {
+	concurrency := e.opts.Prefetch.Concurrency
+	if concurrency <= 0 || e.LintMode || fixtures.isReplay() {
+		return
+	}
+
+	prefetcher := *e
+	prefetcher.prefetchOnly = true
+	funcMap := prefetcher.TxtFuncMap(tmpl)
+
+	callList := []templateFunctionCall{}
+	callKeys := map[string]bool{}
+	for _, call := range collectReachableTemplateFunctionCalls(tmpl) {
+		if call.Dynamic || !prefetchFunctions[canonicalTemplateFunctionName(call.Name)] {
+			continue
+		}
+
+		callKey := fmt.Sprintf("%v:%#v", canonicalTemplateFunctionName(call.Name), call.Args)
+		if callKeys[callKey] {
+			continue
+		}
+		callKeys[callKey] = true
+		callList = append(callList, call)
+	}
+
+	if len(callList) == 0 {
+		return
+	}
+
+	e.logger.Info(`prefetching template function calls`, slog.Int("calls", len(callList)), slog.Int("concurrency", concurrency))
+
+	wg := sync.WaitGroup{}
+	semaphore := make(chan struct{}, concurrency)
+	for _, call := range callList {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			// errors are reported when the template is executed
+			if _, err := callTemplateFunction(call.Name, funcMap[call.Name], call.Args); err != nil {
+				e.logger.Debug(`prefetch of template function call failed`, slog.String("function", call.Name), slog.String("location", call.Location), slog.Any("error", err))
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,95 @@
+package azuretpl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azappconfig"
+	"github.com/webdevops/go-common/utils/to"
+)
+
+func (p *azureSdkProvider) appConfigClient(appConfigUrl string) (*azappconfig.Client, error) {
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := azappconfig.NewClient(appConfigUrl, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create appconfig client for instance "%v": %w`, appConfigUrl, err)
+	}
+	return client, nil
+}
+
+func (p *azureSdkProvider) GetSetting(ctx context.Context, appConfigUrl, key, label string) (azappconfig.GetSettingResponse, error) {
+	client, err := p.appConfigClient(appConfigUrl)
+	if err != nil {
+		return azappconfig.GetSettingResponse{}, err
+	}
+
+	options := azappconfig.GetSettingOptions{}
+	if label != "" {
+		options.Label = to.StringPtr(label)
+	}
+
+	return client.GetSetting(ctx, key, &options)
+}
+
+func (p *azureSdkProvider) ListSettings(ctx context.Context, appConfigUrl, keyFilter, labelFilter string) ([]azappconfig.Setting, error) {
+	client, err := p.appConfigClient(appConfigUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := azappconfig.SettingSelector{
+		KeyFilter: to.StringPtr(keyFilter),
+		Fields:    azappconfig.AllSettingFields(),
+	}
+	if labelFilter != "" {
+		selector.LabelFilter = to.StringPtr(labelFilter)
+	}
+
+	ret := []azappconfig.Setting{}
+	pager := client.NewListSettingsPager(selector, nil)
+	for pager.More() {
+		result, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, result.Settings...)
+	}
+
+	return ret, nil
+}
+
+func (p *azureSdkProvider) GetSnapshot(ctx context.Context, appConfigUrl, snapshotName string) (azappconfig.GetSnapshotResponse, error) {
+	client, err := p.appConfigClient(appConfigUrl)
+	if err != nil {
+		return azappconfig.GetSnapshotResponse{}, err
+	}
+
+	return client.GetSnapshot(ctx, snapshotName, nil)
+}
+
+func (p *azureSdkProvider) ListSnapshotSettings(ctx context.Context, appConfigUrl, snapshotName string) ([]azappconfig.Setting, error) {
+	client, err := p.appConfigClient(appConfigUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	options := azappconfig.ListSettingsForSnapshotOptions{
+		Select: azappconfig.AllSettingFields(),
+	}
+
+	ret := []azappconfig.Setting{}
+	pager := client.NewListSettingsForSnapshotPager(snapshotName, &options)
+	for pager.More() {
+		result, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, result.Settings...)
+	}
+
+	return ret, nil
+}
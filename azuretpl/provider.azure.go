@@ -0,0 +1,69 @@
+package azuretpl
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	msgraphsdk "github.com/microsoftgraph/msgraph-sdk-go"
+)
+
+type (
+	// azureSdkProvider implements all providers using the Azure SDK and the MsGraph SDK
+	azureSdkProvider struct {
+		msGraphClient func() (*msgraphsdk.GraphServiceClient, error)
+
+		// armClientOptions and azCoreClientOptions are the client options of the Azure SDK clients (including the cloud config)
+		armClientOptions    func() (*arm.ClientOptions, error)
+		azCoreClientOptions func() (*azcore.ClientOptions, error)
+
+		// credential is the credential of the selected auth method (see --auth.method)
+		credential func() (azcore.TokenCredential, error)
+	}
+)
+
+func newAzureSdkProviders(e *AzureTemplateExecutor) Providers {
+	provider := &azureSdkProvider{
+		msGraphClient:       e.msGraphClient,
+		armClientOptions:    e.armClientOptions,
+		azCoreClientOptions: e.azCoreClientOptions,
+		credential:          e.credential,
+	}
+
+	return Providers{
+		KeyVault:      provider,
+		AppConfig:     provider,
+		Resource:      provider,
+		ResourceGraph: provider,
+		MsGraph:       provider,
+		Storage:       provider,
+	}
+}
+
+// replaceAzureSdkProviders replaces all Azure SDK providers of current (eg. for another auth profile),
+// custom providers set by SetProviders are kept
+func replaceAzureSdkProviders(current, replacement Providers) Providers {
+	if _, ok := current.KeyVault.(*azureSdkProvider); ok {
+		current.KeyVault = replacement.KeyVault
+	}
+
+	if _, ok := current.AppConfig.(*azureSdkProvider); ok {
+		current.AppConfig = replacement.AppConfig
+	}
+
+	if _, ok := current.Resource.(*azureSdkProvider); ok {
+		current.Resource = replacement.Resource
+	}
+
+	if _, ok := current.ResourceGraph.(*azureSdkProvider); ok {
+		current.ResourceGraph = replacement.ResourceGraph
+	}
+
+	if _, ok := current.MsGraph.(*azureSdkProvider); ok {
+		current.MsGraph = replacement.MsGraph
+	}
+
+	if _, ok := current.Storage.(*azureSdkProvider); ok {
+		current.Storage = replacement.Storage
+	}
+
+	return current
+}
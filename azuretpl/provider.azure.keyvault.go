@@ -0,0 +1,151 @@
+package azuretpl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azcertificates"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+func (p *azureSdkProvider) keyVaultClient(vaultUrl string) (*azsecrets.Client, error) {
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	secretClient, err := azsecrets.NewClient(vaultUrl, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create keyvault client for vault "%v": %w`, vaultUrl, err)
+	}
+	return secretClient, nil
+}
+
+func (p *azureSdkProvider) GetSecret(ctx context.Context, vaultUrl, secretName, version string) (azsecrets.Secret, error) {
+	secretClient, err := p.keyVaultClient(vaultUrl)
+	if err != nil {
+		return azsecrets.Secret{}, err
+	}
+
+	secret, err := secretClient.GetSecret(ctx, secretName, version, nil)
+	if err != nil {
+		return azsecrets.Secret{}, err
+	}
+
+	return secret.Secret, nil
+}
+
+func (p *azureSdkProvider) ListSecretVersions(ctx context.Context, vaultUrl, secretName string) ([]*azsecrets.SecretProperties, error) {
+	secretClient, err := p.keyVaultClient(vaultUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*azsecrets.SecretProperties{}
+	pager := secretClient.NewListSecretPropertiesVersionsPager(secretName, nil)
+	for pager.More() {
+		result, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, result.Value...)
+	}
+
+	return ret, nil
+}
+
+func (p *azureSdkProvider) ListSecrets(ctx context.Context, vaultUrl string) ([]*azsecrets.SecretProperties, error) {
+	secretClient, err := p.keyVaultClient(vaultUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*azsecrets.SecretProperties{}
+	pager := secretClient.NewListSecretPropertiesPager(nil)
+	for pager.More() {
+		result, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, result.Value...)
+	}
+
+	return ret, nil
+}
+
+func (p *azureSdkProvider) keyVaultCertificateClient(vaultUrl string) (*azcertificates.Client, error) {
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	certificateClient, err := azcertificates.NewClient(vaultUrl, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create keyvault certificate client for vault "%v": %w`, vaultUrl, err)
+	}
+	return certificateClient, nil
+}
+
+func (p *azureSdkProvider) GetCertificate(ctx context.Context, vaultUrl, certificateName, version string) (azcertificates.Certificate, error) {
+	certificateClient, err := p.keyVaultCertificateClient(vaultUrl)
+	if err != nil {
+		return azcertificates.Certificate{}, err
+	}
+
+	certificate, err := certificateClient.GetCertificate(ctx, certificateName, version, nil)
+	if err != nil {
+		return azcertificates.Certificate{}, err
+	}
+
+	return certificate.Certificate, nil
+}
+
+func (p *azureSdkProvider) ListCertificates(ctx context.Context, vaultUrl string) ([]*azcertificates.CertificateProperties, error) {
+	certificateClient, err := p.keyVaultCertificateClient(vaultUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*azcertificates.CertificateProperties{}
+	pager := certificateClient.NewListCertificatePropertiesPager(nil)
+	for pager.More() {
+		result, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, result.Value...)
+	}
+
+	return ret, nil
+}
+
+func (p *azureSdkProvider) keyVaultKeyClient(vaultUrl string) (*azkeys.Client, error) {
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	keyClient, err := azkeys.NewClient(vaultUrl, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create keyvault key client for vault "%v": %w`, vaultUrl, err)
+	}
+	return keyClient, nil
+}
+
+func (p *azureSdkProvider) GetKey(ctx context.Context, vaultUrl, keyName, version string) (azkeys.KeyBundle, error) {
+	keyClient, err := p.keyVaultKeyClient(vaultUrl)
+	if err != nil {
+		return azkeys.KeyBundle{}, err
+	}
+
+	key, err := keyClient.GetKey(ctx, keyName, version, nil)
+	if err != nil {
+		return azkeys.KeyBundle{}, err
+	}
+
+	return key.KeyBundle, nil
+}
@@ -0,0 +1,69 @@
+package azuretpl
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/webdevops/go-common/utils/to"
+)
+
+func (p *azureSdkProvider) Query(ctx context.Context, query string, subscriptions, managementGroups []string) ([]map[string]interface{}, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armresourcegraph.NewClient(cred, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	resultFormat := armresourcegraph.ResultFormatObjectArray
+	request := armresourcegraph.QueryRequest{
+		Query: to.StringPtr(query),
+		Options: &armresourcegraph.QueryRequestOptions{
+			ResultFormat: &resultFormat,
+		},
+	}
+
+	for _, subscription := range subscriptions {
+		request.Subscriptions = append(request.Subscriptions, to.StringPtr(subscription))
+	}
+
+	for _, managementGroup := range managementGroups {
+		request.ManagementGroups = append(request.ManagementGroups, to.StringPtr(managementGroup))
+	}
+
+	ret := []map[string]interface{}{}
+	for {
+		result, err := client.Resources(ctx, request, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// normalize result rows to their json representation
+		data, err := json.Marshal(result.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		rows := []map[string]interface{}{}
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, err
+		}
+		ret = append(ret, rows...)
+
+		if result.SkipToken == nil || *result.SkipToken == "" {
+			break
+		}
+		request.Options.SkipToken = result.SkipToken
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,437 @@
+package azuretpl
+
+import (
+	"context"
+	"fmt"
+
+	armauthorization "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/eventhub/armeventhub"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/managementgroups/armmanagementgroups"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/redis/armredis"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/webdevops/go-common/azuresdk/armclient"
+	"github.com/webdevops/go-common/utils/to"
+)
+
+func (p *azureSdkProvider) parseResourceID(resourceID string) (*armclient.AzureResourceDetails, error) {
+	resourceInfo, err := armclient.ParseResourceId(resourceID)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to parse Azure resourceID '%v': %w`, resourceID, err)
+	}
+	return resourceInfo, nil
+}
+
+func (p *azureSdkProvider) GetResourceByID(ctx context.Context, resourceID, apiVersion string) (armresources.GenericResource, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return armresources.GenericResource{}, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return armresources.GenericResource{}, err
+	}
+
+	resourceInfo, err := p.parseResourceID(resourceID)
+	if err != nil {
+		return armresources.GenericResource{}, err
+	}
+
+	client, err := armresources.NewClient(resourceInfo.Subscription, cred, clientOptions)
+	if err != nil {
+		return armresources.GenericResource{}, err
+	}
+
+	resource, err := client.GetByID(ctx, resourceID, apiVersion, nil)
+	if err != nil {
+		return armresources.GenericResource{}, err
+	}
+
+	return resource.GenericResource, nil
+}
+
+func (p *azureSdkProvider) ListResources(ctx context.Context, scope, filter string) ([]*armresources.GenericResourceExpanded, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	scopeInfo, err := p.parseResourceID(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armresources.NewClient(scopeInfo.Subscription, cred, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*armresources.GenericResourceExpanded{}
+	if scopeInfo.ResourceGroup != "" {
+		// list by ResourceGroup
+		options := armresources.ClientListByResourceGroupOptions{}
+		if filter != "" {
+			options.Filter = to.StringPtr(filter)
+		}
+
+		pager := client.NewListByResourceGroupPager(scopeInfo.ResourceGroup, &options)
+		for pager.More() {
+			result, err := pager.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			ret = append(ret, result.Value...)
+		}
+	} else {
+		// list by Subscription
+		options := armresources.ClientListOptions{}
+		if filter != "" {
+			options.Filter = to.StringPtr(filter)
+		}
+
+		pager := client.NewListPager(&options)
+		for pager.More() {
+			result, err := pager.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			ret = append(ret, result.Value...)
+		}
+	}
+
+	return ret, nil
+}
+
+func (p *azureSdkProvider) GetSubscription(ctx context.Context, subscriptionID string) (armsubscriptions.Subscription, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return armsubscriptions.Subscription{}, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return armsubscriptions.Subscription{}, err
+	}
+
+	client, err := armsubscriptions.NewClient(cred, clientOptions)
+	if err != nil {
+		return armsubscriptions.Subscription{}, err
+	}
+
+	resource, err := client.Get(ctx, subscriptionID, nil)
+	if err != nil {
+		return armsubscriptions.Subscription{}, err
+	}
+
+	return resource.Subscription, nil
+}
+
+func (p *azureSdkProvider) ListSubscriptions(ctx context.Context) ([]*armsubscriptions.Subscription, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armsubscriptions.NewClient(cred, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*armsubscriptions.Subscription{}
+	pager := client.NewListPager(nil)
+	for pager.More() {
+		result, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, result.Value...)
+	}
+
+	return ret, nil
+}
+
+func (p *azureSdkProvider) GetManagementGroup(ctx context.Context, groupID string) (armmanagementgroups.ManagementGroup, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return armmanagementgroups.ManagementGroup{}, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return armmanagementgroups.ManagementGroup{}, err
+	}
+
+	client, err := armmanagementgroups.NewClient(cred, clientOptions)
+	if err != nil {
+		return armmanagementgroups.ManagementGroup{}, fmt.Errorf(`failed to create ManagementGroup client "%v": %w`, groupID, err)
+	}
+
+	managementGroup, err := client.Get(ctx, groupID, nil)
+	if err != nil {
+		return armmanagementgroups.ManagementGroup{}, err
+	}
+
+	return managementGroup.ManagementGroup, nil
+}
+
+func (p *azureSdkProvider) ListManagementGroupDescendants(ctx context.Context, groupID string) ([]*armmanagementgroups.DescendantInfo, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armmanagementgroups.NewClient(cred, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create ManagementGroup client "%v": %w`, groupID, err)
+	}
+
+	ret := []*armmanagementgroups.DescendantInfo{}
+	pager := client.NewGetDescendantsPager(groupID, nil)
+	for pager.More() {
+		result, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, result.Value...)
+	}
+
+	return ret, nil
+}
+
+func (p *azureSdkProvider) GetPublicIPAddress(ctx context.Context, resourceID string) (armnetwork.PublicIPAddress, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return armnetwork.PublicIPAddress{}, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return armnetwork.PublicIPAddress{}, err
+	}
+
+	resourceInfo, err := p.parseResourceID(resourceID)
+	if err != nil {
+		return armnetwork.PublicIPAddress{}, err
+	}
+
+	client, err := armnetwork.NewPublicIPAddressesClient(resourceInfo.Subscription, cred, clientOptions)
+	if err != nil {
+		return armnetwork.PublicIPAddress{}, err
+	}
+
+	pipAddress, err := client.Get(ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+	if err != nil {
+		return armnetwork.PublicIPAddress{}, err
+	}
+
+	return pipAddress.PublicIPAddress, nil
+}
+
+func (p *azureSdkProvider) GetPublicIPPrefix(ctx context.Context, resourceID string) (armnetwork.PublicIPPrefix, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return armnetwork.PublicIPPrefix{}, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return armnetwork.PublicIPPrefix{}, err
+	}
+
+	resourceInfo, err := p.parseResourceID(resourceID)
+	if err != nil {
+		return armnetwork.PublicIPPrefix{}, err
+	}
+
+	client, err := armnetwork.NewPublicIPPrefixesClient(resourceInfo.Subscription, cred, clientOptions)
+	if err != nil {
+		return armnetwork.PublicIPPrefix{}, err
+	}
+
+	pipPrefix, err := client.Get(ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+	if err != nil {
+		return armnetwork.PublicIPPrefix{}, err
+	}
+
+	return pipPrefix.PublicIPPrefix, nil
+}
+
+func (p *azureSdkProvider) GetVirtualNetwork(ctx context.Context, resourceID string) (armnetwork.VirtualNetwork, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return armnetwork.VirtualNetwork{}, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return armnetwork.VirtualNetwork{}, err
+	}
+
+	resourceInfo, err := p.parseResourceID(resourceID)
+	if err != nil {
+		return armnetwork.VirtualNetwork{}, err
+	}
+
+	client, err := armnetwork.NewVirtualNetworksClient(resourceInfo.Subscription, cred, clientOptions)
+	if err != nil {
+		return armnetwork.VirtualNetwork{}, err
+	}
+
+	vnet, err := client.Get(ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+	if err != nil {
+		return armnetwork.VirtualNetwork{}, err
+	}
+
+	return vnet.VirtualNetwork, nil
+}
+
+func (p *azureSdkProvider) ListRedisAccessKeys(ctx context.Context, resourceID string) (armredis.AccessKeys, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return armredis.AccessKeys{}, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return armredis.AccessKeys{}, err
+	}
+
+	resourceInfo, err := p.parseResourceID(resourceID)
+	if err != nil {
+		return armredis.AccessKeys{}, err
+	}
+
+	client, err := armredis.NewClient(resourceInfo.Subscription, cred, clientOptions)
+	if err != nil {
+		return armredis.AccessKeys{}, err
+	}
+
+	result, err := client.ListKeys(ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+	if err != nil {
+		return armredis.AccessKeys{}, err
+	}
+
+	return result.AccessKeys, nil
+}
+
+func (p *azureSdkProvider) ListEventHubsByNamespace(ctx context.Context, resourceID string) ([]*armeventhub.Eventhub, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	resourceInfo, err := p.parseResourceID(resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armeventhub.NewEventHubsClient(resourceInfo.Subscription, cred, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create EventHubsClient "%v": %w`, resourceID, err)
+	}
+
+	ret := []*armeventhub.Eventhub{}
+	pager := client.NewListByNamespacePager(resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+	for pager.More() {
+		result, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, result.Value...)
+	}
+
+	return ret, nil
+}
+
+func (p *azureSdkProvider) ListManagedClusterUserCredentials(ctx context.Context, resourceID string) (armcontainerservice.CredentialResults, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return armcontainerservice.CredentialResults{}, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return armcontainerservice.CredentialResults{}, err
+	}
+
+	resourceInfo, err := p.parseResourceID(resourceID)
+	if err != nil {
+		return armcontainerservice.CredentialResults{}, err
+	}
+
+	client, err := armcontainerservice.NewManagedClustersClient(resourceInfo.Subscription, cred, clientOptions)
+	if err != nil {
+		return armcontainerservice.CredentialResults{}, fmt.Errorf(`failed to create ManagedCluster client for cluster "%v": %w`, resourceID, err)
+	}
+
+	userCreds, err := client.ListClusterUserCredentials(ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+	if err != nil {
+		return armcontainerservice.CredentialResults{}, err
+	}
+
+	return userCreds.CredentialResults, nil
+}
+
+func (p *azureSdkProvider) ListRoleDefinitions(ctx context.Context, scope, filter string) ([]armauthorization.RoleDefinition, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armauthorization.NewRoleDefinitionsClient(cred, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := armauthorization.RoleDefinitionsClientListOptions{
+		Filter: to.StringPtr(filter),
+	}
+	pager := client.NewListPager(scope, &listOpts)
+
+	list := []armauthorization.RoleDefinition{}
+	for pager.More() {
+		result, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, roleDefinition := range result.Value {
+			list = append(list, *roleDefinition)
+		}
+	}
+
+	return list, nil
+}
@@ -0,0 +1,73 @@
+package azuretpl
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/webdevops/go-common/azuresdk/armclient"
+)
+
+func (p *azureSdkProvider) ListAccountKeys(ctx context.Context, resourceID string) ([]*armstorage.AccountKey, error) {
+	clientOptions, err := p.armClientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	resourceInfo, err := armclient.ParseResourceId(resourceID)
+	if err != nil {
+		return nil, fmt.Errorf(`unable to parse Azure resourceID '%v': %w`, resourceID, err)
+	}
+
+	client, err := armstorage.NewAccountsClient(resourceInfo.Subscription, cred, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := client.ListKeys(ctx, resourceInfo.ResourceGroup, resourceInfo.ResourceName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Keys, nil
+}
+
+func (p *azureSdkProvider) DownloadBlob(ctx context.Context, containerBlobUrl string) ([]byte, error) {
+	clientOptions, err := p.azCoreClientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	pathUrl, err := azblob.ParseURL(containerBlobUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	azblobOpts := azblob.ClientOptions{ClientOptions: *clientOptions}
+
+	storageAccountUrl := fmt.Sprintf("%s://%s", pathUrl.Scheme, pathUrl.Host)
+	client, err := azblob.NewClient(storageAccountUrl, cred, &azblobOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := client.DownloadStream(ctx, pathUrl.ContainerName, pathUrl.BlobName, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close() // nolint: errcheck
+
+	return io.ReadAll(response.Body)
+}
@@ -0,0 +1,103 @@
+package azuretpl
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azappconfig"
+	armauthorization "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/eventhub/armeventhub"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/managementgroups/armmanagementgroups"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/redis/armredis"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azcertificates"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+type (
+	// KeyVaultProvider provides access to Azure KeyVault secrets, certificates and keys
+	KeyVaultProvider interface {
+		// GetSecret fetches one secret (latest version if version is empty)
+		GetSecret(ctx context.Context, vaultUrl, secretName, version string) (azsecrets.Secret, error)
+		// ListSecretVersions fetches the properties of all versions of one secret
+		ListSecretVersions(ctx context.Context, vaultUrl, secretName string) ([]*azsecrets.SecretProperties, error)
+		// ListSecrets fetches the properties (without values) of all secrets
+		ListSecrets(ctx context.Context, vaultUrl string) ([]*azsecrets.SecretProperties, error)
+		// GetCertificate fetches one certificate without private key (latest version if version is empty)
+		GetCertificate(ctx context.Context, vaultUrl, certificateName, version string) (azcertificates.Certificate, error)
+		// ListCertificates fetches the properties of all certificates
+		ListCertificates(ctx context.Context, vaultUrl string) ([]*azcertificates.CertificateProperties, error)
+		// GetKey fetches the public part of one key (latest version if version is empty)
+		GetKey(ctx context.Context, vaultUrl, keyName, version string) (azkeys.KeyBundle, error)
+	}
+
+	// AppConfigProvider provides access to Azure AppConfig settings
+	AppConfigProvider interface {
+		// GetSetting fetches one setting (without label if label is empty)
+		GetSetting(ctx context.Context, appConfigUrl, key, label string) (azappconfig.GetSettingResponse, error)
+		// ListSettings fetches all settings matching key and label filter (eg. "myapp:*" and "prod,\0")
+		ListSettings(ctx context.Context, appConfigUrl, keyFilter, labelFilter string) ([]azappconfig.Setting, error)
+		// GetSnapshot fetches the properties of one snapshot
+		GetSnapshot(ctx context.Context, appConfigUrl, snapshotName string) (azappconfig.GetSnapshotResponse, error)
+		// ListSnapshotSettings fetches all settings of one snapshot
+		ListSnapshotSettings(ctx context.Context, appConfigUrl, snapshotName string) ([]azappconfig.Setting, error)
+	}
+
+	// ResourceProvider provides access to Azure resources using the ARM API
+	ResourceProvider interface {
+		GetResourceByID(ctx context.Context, resourceID, apiVersion string) (armresources.GenericResource, error)
+		// ListResources fetches resources by scope (subscription or resourceGroup) using an optional $filter
+		ListResources(ctx context.Context, scope, filter string) ([]*armresources.GenericResourceExpanded, error)
+		GetSubscription(ctx context.Context, subscriptionID string) (armsubscriptions.Subscription, error)
+		ListSubscriptions(ctx context.Context) ([]*armsubscriptions.Subscription, error)
+		GetManagementGroup(ctx context.Context, groupID string) (armmanagementgroups.ManagementGroup, error)
+		ListManagementGroupDescendants(ctx context.Context, groupID string) ([]*armmanagementgroups.DescendantInfo, error)
+		GetPublicIPAddress(ctx context.Context, resourceID string) (armnetwork.PublicIPAddress, error)
+		GetPublicIPPrefix(ctx context.Context, resourceID string) (armnetwork.PublicIPPrefix, error)
+		GetVirtualNetwork(ctx context.Context, resourceID string) (armnetwork.VirtualNetwork, error)
+		ListRedisAccessKeys(ctx context.Context, resourceID string) (armredis.AccessKeys, error)
+		ListEventHubsByNamespace(ctx context.Context, resourceID string) ([]*armeventhub.Eventhub, error)
+		ListManagedClusterUserCredentials(ctx context.Context, resourceID string) (armcontainerservice.CredentialResults, error)
+		ListRoleDefinitions(ctx context.Context, scope, filter string) ([]armauthorization.RoleDefinition, error)
+	}
+
+	// ResourceGraphProvider provides access to Azure ResourceGraph
+	ResourceGraphProvider interface {
+		Query(ctx context.Context, query string, subscriptions, managementGroups []string) ([]map[string]interface{}, error)
+	}
+
+	// MsGraphProvider provides access to MsGraph directory objects,
+	// all objects are returned in their json representation
+	MsGraphProvider interface {
+		ListUsers(ctx context.Context, filter string) ([]interface{}, error)
+		ListGroups(ctx context.Context, filter string) ([]interface{}, error)
+		ListServicePrincipals(ctx context.Context, filter string) ([]interface{}, error)
+		ListApplications(ctx context.Context, filter string) ([]interface{}, error)
+	}
+
+	// StorageProvider provides access to Azure StorageAccounts
+	StorageProvider interface {
+		ListAccountKeys(ctx context.Context, resourceID string) ([]*armstorage.AccountKey, error)
+		DownloadBlob(ctx context.Context, containerBlobUrl string) ([]byte, error)
+	}
+
+	// Providers are the backends used by the template functions,
+	// unset providers are using the Azure SDK implementations
+	Providers struct {
+		KeyVault      KeyVaultProvider
+		AppConfig     AppConfigProvider
+		Resource      ResourceProvider
+		ResourceGraph ResourceGraphProvider
+		MsGraph       MsGraphProvider
+		Storage       StorageProvider
+	}
+)
+
+// isEmpty returns true if no provider is set
+func (p Providers) isEmpty() bool {
+	return p.KeyVault == nil && p.AppConfig == nil && p.Resource == nil && p.ResourceGraph == nil && p.MsGraph == nil && p.Storage == nil
+}
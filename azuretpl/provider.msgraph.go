@@ -0,0 +1,156 @@
+package azuretpl
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/microsoft/kiota-abstractions-go/serialization"
+	msgraphsdk "github.com/microsoftgraph/msgraph-sdk-go"
+	msgraphcore "github.com/microsoftgraph/msgraph-sdk-go-core"
+	"github.com/microsoftgraph/msgraph-sdk-go/applications"
+	"github.com/microsoftgraph/msgraph-sdk-go/groups"
+	"github.com/microsoftgraph/msgraph-sdk-go/models"
+	"github.com/microsoftgraph/msgraph-sdk-go/serviceprincipals"
+	"github.com/microsoftgraph/msgraph-sdk-go/users"
+	"github.com/webdevops/go-common/utils/to"
+)
+
+func (p *azureSdkProvider) ListUsers(ctx context.Context, filter string) ([]interface{}, error) {
+	var requestOpts *users.UsersRequestBuilderGetRequestConfiguration
+	if filter != "" {
+		requestOpts = &users.UsersRequestBuilderGetRequestConfiguration{
+			QueryParameters: &users.UsersRequestBuilderGetQueryParameters{
+				Filter: to.StringPtr(filter),
+			},
+		}
+	}
+
+	graphClient, err := p.msGraphClient()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := graphClient.Users().Get(ctx, requestOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return msGraphCreateListFromResult[models.Userable](ctx, graphClient, result, models.CreateUserCollectionResponseFromDiscriminatorValue)
+}
+
+func (p *azureSdkProvider) ListGroups(ctx context.Context, filter string) ([]interface{}, error) {
+	var requestOpts *groups.GroupsRequestBuilderGetRequestConfiguration
+	if filter != "" {
+		requestOpts = &groups.GroupsRequestBuilderGetRequestConfiguration{
+			QueryParameters: &groups.GroupsRequestBuilderGetQueryParameters{
+				Filter: to.StringPtr(filter),
+			},
+		}
+	}
+
+	graphClient, err := p.msGraphClient()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := graphClient.Groups().Get(ctx, requestOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return msGraphCreateListFromResult[models.Groupable](ctx, graphClient, result, models.CreateGroupCollectionResponseFromDiscriminatorValue)
+}
+
+func (p *azureSdkProvider) ListServicePrincipals(ctx context.Context, filter string) ([]interface{}, error) {
+	var requestOpts *serviceprincipals.ServicePrincipalsRequestBuilderGetRequestConfiguration
+	if filter != "" {
+		requestOpts = &serviceprincipals.ServicePrincipalsRequestBuilderGetRequestConfiguration{
+			QueryParameters: &serviceprincipals.ServicePrincipalsRequestBuilderGetQueryParameters{
+				Filter: to.StringPtr(filter),
+			},
+		}
+	}
+
+	graphClient, err := p.msGraphClient()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := graphClient.ServicePrincipals().Get(ctx, requestOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return msGraphCreateListFromResult[models.ServicePrincipalable](ctx, graphClient, result, models.CreateServicePrincipalCollectionResponseFromDiscriminatorValue)
+}
+
+func (p *azureSdkProvider) ListApplications(ctx context.Context, filter string) ([]interface{}, error) {
+	var requestOpts *applications.ApplicationsRequestBuilderGetRequestConfiguration
+	if filter != "" {
+		requestOpts = &applications.ApplicationsRequestBuilderGetRequestConfiguration{
+			QueryParameters: &applications.ApplicationsRequestBuilderGetQueryParameters{
+				Filter: to.StringPtr(filter),
+			},
+		}
+	}
+
+	graphClient, err := p.msGraphClient()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := graphClient.Applications().Get(ctx, requestOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return msGraphCreateListFromResult[models.Applicationable](ctx, graphClient, result, models.CreateApplicationCollectionResponseFromDiscriminatorValue)
+}
+
+// msGraphCreateListFromResult iterates over all pages of a MsGraph collection response and serializes all objects
+func msGraphCreateListFromResult[T serialization.Parsable](ctx context.Context, graphClient *msgraphsdk.GraphServiceClient, result interface{}, constructor serialization.ParsableFactory) (list []interface{}, err error) {
+	pageIterator, pageIteratorErr := msgraphcore.NewPageIterator[T](result, graphClient.GetAdapter(), constructor)
+	if pageIteratorErr != nil {
+		return list, pageIteratorErr
+	}
+
+	iterateErr := pageIterator.Iterate(ctx, func(item T) bool {
+		obj, serializeErr := msGraphSerializeObject(graphClient, item)
+		if serializeErr != nil {
+			err = serializeErr
+			return false
+		}
+
+		list = append(list, obj)
+		return true
+	})
+	if iterateErr != nil {
+		return list, iterateErr
+	}
+
+	return
+}
+
+func msGraphSerializeObject(graphClient *msgraphsdk.GraphServiceClient, resultObj serialization.Parsable) (obj interface{}, err error) {
+	writer, err := graphClient.GetAdapter().GetSerializationWriterFactory().GetSerializationWriter("application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.WriteObjectValue("", resultObj)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedValue, err := writer.GetSerializedContent()
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(serializedValue, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
@@ -0,0 +1,116 @@
+package azuretpl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+
+	"github.com/webdevops/go-common/log/slogger"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
+)
+
+type (
+	// RenderOptions configures rendering of templates when using helm-azure-tpl as library
+	RenderOptions struct {
+		// Opts are the template function options (eg. KeyVault expiry handling)
+		Opts models.Opts
+
+		// Logger is used for logging, logs are discarded if not set
+		Logger *slogger.Logger
+
+		// UserAgent is sent to the Azure APIs
+		UserAgent string
+
+		// LintMode enables lint mode, all Azure functions are in dry mode
+		// and findings are collected instead of returned as errors (see AzureTemplateExecutor.LintFindings)
+		LintMode bool
+
+		// RootPath is the path used for absolute paths inside templates (filesGet, filesGlob, include),
+		// defaults to the current directory (or the root of FS)
+		RootPath string
+
+		// RelPath is the path used for relative paths inside templates (filesGet, filesGlob, include),
+		// defaults to RootPath
+		RelPath string
+
+		// FS is used to read files inside templates instead of the local filesystem
+		FS fs.FS
+
+		// Providers replaces the Azure backends (eg. for tests), unset providers are using the Azure SDK,
+		// results of custom providers are not stored in the persistent cache
+		Providers Providers
+	}
+)
+
+// NewFromRenderOptions creates a new template executor configured by RenderOptions,
+// the executor uses its own cache, lock file entries and summary (executors are not sharing results)
+func NewFromRenderOptions(ctx context.Context, options RenderOptions) (*AzureTemplateExecutor, error) {
+	logger := options.Logger
+	if logger == nil {
+		logger = slogger.NewCliLogger(io.Discard)
+	}
+
+	e := New(ctx, options.Opts, logger)
+	e.cache = newResultCache()
+	e.lockFile = newLockStore()
+	e.summary = newSummaryStore()
+	e.SetUserAgent(options.UserAgent)
+	e.SetLintMode(options.LintMode)
+	e.SetProviders(options.Providers)
+	if options.FS != nil {
+		e.SetFS(options.FS)
+	}
+
+	rootPath := options.RootPath
+	if rootPath == "" {
+		rootPath = "."
+	}
+
+	relPath := options.RelPath
+	if relPath == "" {
+		relPath = rootPath
+	}
+
+	if err := e.SetTemplateRootPath(rootPath); err != nil {
+		return nil, err
+	}
+
+	if err := e.SetTemplateRelPath(relPath); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Render processes template read from r and writes the result to w
+func Render(ctx context.Context, name string, r io.Reader, data interface{}, w io.Writer, options RenderOptions) error {
+	e, err := NewFromRenderOptions(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	return e.ParseReader(name, r, data, w)
+}
+
+// RenderFile processes template file read from fsys and writes the result to w,
+// relative paths inside the template are resolved from the directory of the template file (if RelPath is not set)
+func RenderFile(ctx context.Context, fsys fs.FS, filePath string, data interface{}, w io.Writer, options RenderOptions) error {
+	if fsys == nil {
+		return fmt.Errorf(`no filesystem for template "%v" specified`, filePath)
+	}
+
+	options.FS = fsys
+	if options.RelPath == "" {
+		options.RelPath = path.Dir(filePath)
+	}
+
+	e, err := NewFromRenderOptions(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	return e.Parse(e.fsMakePath("/"+filePath), data, w)
+}
@@ -0,0 +1,54 @@
+package azuretpl
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+type fakeKeyVaultProvider struct {
+	KeyVaultProvider
+
+	value string
+}
+
+func (p fakeKeyVaultProvider) GetSecret(ctx context.Context, vaultUrl, secretName, version string) (azsecrets.Secret, error) {
+	id := azsecrets.ID(vaultUrl + "/secrets/" + secretName + "/v1")
+	return azsecrets.Secret{ID: &id, Value: &p.value}, nil
+}
+
+func TestRenderWithProvidersIsIsolated(t *testing.T) {
+	template := `{{ (azKeyVaultSecret "https://vault.example.com" "secret").value }}`
+
+	values := []string{"first", "second", "third", "fourth"}
+	results := make([]string, len(values))
+	errList := make([]error, len(values))
+
+	wg := sync.WaitGroup{}
+	for i, value := range values {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var buf strings.Builder
+			errList[i] = Render(context.Background(), "test.tpl", strings.NewReader(template), nil, &buf, RenderOptions{
+				Providers: Providers{KeyVault: fakeKeyVaultProvider{value: value}},
+			})
+			results[i] = buf.String()
+		}()
+	}
+	wg.Wait()
+
+	for i, value := range values {
+		if errList[i] != nil {
+			t.Fatalf("unexpected error: %v", errList[i])
+		}
+
+		if results[i] != value {
+			t.Errorf("expected %q, got %q", value, results[i])
+		}
+	}
+}
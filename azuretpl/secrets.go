@@ -0,0 +1,111 @@
+package azuretpl
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	SecretMaskValue = "***"
+
+	// secretMaskMinLength is the minimal length of secret values which are masked (avoids masking of short common values)
+	secretMaskMinLength = 4
+)
+
+var (
+	secretValues     = map[string]bool{}
+	secretValuesLock sync.RWMutex
+
+	// secretResultFields are fields inside results of secret functions which contain secret values
+	secretResultFields = map[string]bool{
+		"value":        true,
+		"primaryKey":   true,
+		"secondaryKey": true,
+		"tls.key":      true,
+	}
+)
+
+// registerSecretResult remembers all secret values inside result of secret template function
+func registerSecretResult(val interface{}) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if secret, ok := item.(string); ok && secretResultFields[key] {
+				registerSecretValue(secret)
+			} else {
+				registerSecretResult(item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			registerSecretResult(item)
+		}
+	}
+}
+
+func registerSecretValue(val string) {
+	if len(val) < secretMaskMinLength || val == FixtureRedactedValue {
+		return
+	}
+
+	secretValuesLock.Lock()
+	defer secretValuesLock.Unlock()
+	for _, encodedVal := range secretValueEncodings(val) {
+		secretValues[encodedVal] = true
+	}
+}
+
+// secretValueEncodings returns the secret value and its common encodings inside templates
+// (eg. b64enc for kubernetes secrets, quote, toJson and sha256sum)
+func secretValueEncodings(val string) []string {
+	sha256sum := sha256.Sum256([]byte(val))
+	ret := []string{
+		val,
+		base64.StdEncoding.EncodeToString([]byte(val)),
+		base64.RawStdEncoding.EncodeToString([]byte(val)),
+		base64.URLEncoding.EncodeToString([]byte(val)),
+		hex.EncodeToString(sha256sum[:]),
+	}
+
+	// escaped value inside quoted strings (without surrounding quotes)
+	if quoted := strconv.Quote(val); quoted[1:len(quoted)-1] != val {
+		ret = append(ret, quoted[1:len(quoted)-1])
+	}
+	if jsonVal, err := json.Marshal(val); err == nil && string(jsonVal[1:len(jsonVal)-1]) != val {
+		ret = append(ret, string(jsonVal[1:len(jsonVal)-1]))
+	}
+
+	return ret
+}
+
+// ContainsSecretValues returns true if content contains secret values (fetched by template functions)
+func ContainsSecretValues(content string) bool {
+	return MaskSecretValues(content) != content
+}
+
+// MaskSecretValues replaces all secret values (fetched by template functions) inside content
+func MaskSecretValues(content string) string {
+	secretValuesLock.RLock()
+	list := make([]string, 0, len(secretValues))
+	for val := range secretValues {
+		list = append(list, val)
+	}
+	secretValuesLock.RUnlock()
+
+	// replace longest values first (secrets could contain other secrets)
+	sort.Slice(list, func(i, j int) bool {
+		return len(list[i]) > len(list[j])
+	})
+
+	for _, val := range list {
+		content = strings.ReplaceAll(content, val, SecretMaskValue)
+	}
+
+	return content
+}
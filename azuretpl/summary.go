@@ -5,12 +5,13 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	"github.com/webdevops/go-common/log/slogger"
 	"github.com/webdevops/go-common/utils/to"
 
+	"github.com/webdevops/helm-azure-tpl/azuretpl/models"
 	"github.com/webdevops/helm-azure-tpl/config"
 )
 
@@ -19,22 +20,58 @@ const (
 	SummaryValueNotSet = "<n/a>"
 )
 
+type (
+	// summaryStore collects the summary sections and their rows
+	summaryStore struct {
+		lock     sync.RWMutex
+		sections map[string][]string
+
+		// reported are the keys of already reported warnings
+		reported map[string]bool
+	}
+)
+
 var (
-	summary map[string][]string = map[string][]string{}
+	summary = newSummaryStore()
 )
 
+func newSummaryStore() *summaryStore {
+	return &summaryStore{
+		sections: map[string][]string{},
+		reported: map[string]bool{},
+	}
+}
+
+// firstReport returns true if key wasn't reported yet and marks it as reported
+func (s *summaryStore) firstReport(key string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.reported[key] {
+		return false
+	}
+	s.reported[key] = true
+	return true
+}
+
 func (e *AzureTemplateExecutor) addSummaryLine(section, val string) {
-	if _, ok := summary[section]; !ok {
-		summary[section] = []string{}
+	e.summary.lock.Lock()
+	defer e.summary.lock.Unlock()
+
+	if _, ok := e.summary.sections[section]; !ok {
+		e.summary.sections[section] = []string{}
 	}
 
-	summary[section] = append(summary[section], val)
+	e.summary.sections[section] = append(e.summary.sections[section], val)
 }
 
-func (e *AzureTemplateExecutor) addSummaryKeyvaultSecret(vaultUrl string, secret azsecrets.GetSecretResponse) {
+func (e *AzureTemplateExecutor) addSummaryKeyvaultSecret(vaultUrl string, secret *models.AzSecret) {
+	e.summary.lock.Lock()
+	defer e.summary.lock.Unlock()
+
 	section := "Azure Keyvault Secrets"
-	if _, ok := summary[section]; !ok {
-		summary[section] = []string{
+	if _, ok := e.summary.sections[section]; !ok {
+		e.summary.sections[section] = []string{
 			"| KeyVault | Secret | Version | ContentType | Expiry |",
 			"|----------|--------|---------|-------------|--------|",
 		}
@@ -53,16 +90,162 @@ func (e *AzureTemplateExecutor) addSummaryKeyvaultSecret(vaultUrl string, secret
 	val := fmt.Sprintf(
 		"| %s | %s | %s | %s | %s |",
 		vaultUrl,
-		secret.ID.Name(),
-		secret.ID.Version(),
+		secret.Name,
+		secret.Version,
 		contentType,
 		expiryDate,
 	)
 
-	summary[section] = append(summary[section], val)
+	e.summary.appendRow(section, val)
+}
+
+func (e *AzureTemplateExecutor) addSummaryKeyvaultCertificate(vaultUrl string, certificate *models.AzCertificate) {
+	e.summary.lock.Lock()
+	defer e.summary.lock.Unlock()
+
+	section := "Azure Keyvault Certificates"
+	if _, ok := e.summary.sections[section]; !ok {
+		e.summary.sections[section] = []string{
+			"| KeyVault | Certificate | Version | Thumbprint | Expiry |",
+			"|----------|-------------|---------|------------|--------|",
+		}
+	}
+
+	expiryDate := SummaryValueNotSet
+	if certificate.Attributes != nil && certificate.Attributes.Expires != nil {
+		expiryDate = certificate.Attributes.Expires.Format(time.RFC3339)
+	}
+
+	thumbprint := certificate.Thumbprint
+	if thumbprint == "" {
+		thumbprint = SummaryValueNotSet
+	}
+
+	val := fmt.Sprintf(
+		"| %s | %s | %s | %s | %s |",
+		vaultUrl,
+		certificate.Name,
+		certificate.Version,
+		thumbprint,
+		expiryDate,
+	)
+
+	e.summary.appendRow(section, val)
+}
+
+func (e *AzureTemplateExecutor) addSummaryKeyvaultKey(vaultUrl string, key *models.AzKey) {
+	e.summary.lock.Lock()
+	defer e.summary.lock.Unlock()
+
+	section := "Azure Keyvault Keys"
+	if _, ok := e.summary.sections[section]; !ok {
+		e.summary.sections[section] = []string{
+			"| KeyVault | Key | Version | KeyType | Expiry |",
+			"|----------|-----|---------|---------|--------|",
+		}
+	}
+
+	expiryDate := SummaryValueNotSet
+	if key.Attributes != nil && key.Attributes.Expires != nil {
+		expiryDate = key.Attributes.Expires.Format(time.RFC3339)
+	}
+
+	keyType := key.KeyType
+	if keyType == "" {
+		keyType = SummaryValueNotSet
+	}
+
+	val := fmt.Sprintf(
+		"| %s | %s | %s | %s | %s |",
+		vaultUrl,
+		key.Name,
+		key.Version,
+		keyType,
+		expiryDate,
+	)
+
+	e.summary.appendRow(section, val)
+}
+
+// appendRow appends row to section if it doesn't exist yet (same item could be used in multiple templates),
+// lock must be held by caller
+func (s *summaryStore) appendRow(section, val string) {
+	for _, row := range s.sections[section] {
+		if row == val {
+			return
+		}
+	}
+
+	s.sections[section] = append(s.sections[section], val)
+}
+
+func (e *AzureTemplateExecutor) addSummaryAppConfigKeyVaultReference(appConfigUrl string, setting *models.AzAppconfigSetting) {
+	e.summary.lock.Lock()
+	defer e.summary.lock.Unlock()
+
+	section := "Azure AppConfig KeyVault References"
+	if _, ok := e.summary.sections[section]; !ok {
+		e.summary.sections[section] = []string{
+			"| AppConfig | Setting | Label | KeyVault | Secret | Version | Expiry |",
+			"|-----------|---------|-------|----------|--------|---------|--------|",
+		}
+	}
+
+	reference := setting.KeyVaultReference
+
+	label := to.String(setting.Label)
+	if label == "" {
+		label = SummaryValueNotSet
+	}
+
+	expiryDate := SummaryValueNotSet
+	if reference.Expires != nil {
+		expiryDate = reference.Expires.Format(time.RFC3339)
+	}
+
+	val := fmt.Sprintf(
+		"| %s | %s | %s | %s | %s | %s | %s |",
+		appConfigUrl,
+		to.String(setting.Key),
+		label,
+		reference.VaultUrl,
+		reference.SecretName,
+		reference.Version,
+		expiryDate,
+	)
+
+	e.summary.appendRow(section, val)
+}
+
+func (e *AzureTemplateExecutor) addSummaryKeyvaultPolicyViolation(violation KeyVaultPolicyViolation) {
+	e.summary.lock.Lock()
+	defer e.summary.lock.Unlock()
+
+	section := "Azure Keyvault Policy Violations"
+	if _, ok := e.summary.sections[section]; !ok {
+		e.summary.sections[section] = []string{
+			"| Level | KeyVault | Secret | Version | Rule | Message |",
+			"|-------|----------|--------|---------|------|---------|",
+		}
+	}
+
+	val := fmt.Sprintf(
+		"| %s | %s | %s | %s | %s | %s |",
+		violation.Level,
+		violation.Vault,
+		violation.Secret,
+		violation.Version,
+		violation.Rule,
+		violation.Message,
+	)
+
+	e.summary.appendRow(section, val)
 }
 
 func buildSummary(opts config.Opts) string {
+	summary.lock.RLock()
+	defer summary.lock.RUnlock()
+
 	output := []string{SummaryHeader}
 
 	output = append(output, "templates:\n")
@@ -70,7 +253,7 @@ func buildSummary(opts config.Opts) string {
 		output = append(output, fmt.Sprintf("- %s", file))
 	}
 
-	for section, rows := range summary {
+	for section, rows := range summary.sections {
 		output = append(output, fmt.Sprintf("\n### %s\n", section))
 		output = append(output, strings.Join(rows, "\n"))
 	}
@@ -84,7 +267,10 @@ func PostSummary(logger *slogger.Logger, opts config.Opts) {
 	}
 
 	// skip empty summary
-	if len(summary) == 0 {
+	summary.lock.RLock()
+	summaryEmpty := len(summary.sections) == 0
+	summary.lock.RUnlock()
+	if summaryEmpty {
 		return
 	}
 
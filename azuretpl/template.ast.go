@@ -0,0 +1,307 @@
+package azuretpl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	textTemplate "text/template"
+	"text/template/parse"
+)
+
+var (
+	// conditionalTemplateFunctions are functions which don't use all arguments in every case (and/or are short-circuited,
+	// default only uses its default value if the given value is empty), value is the number of leading arguments which are always used
+	conditionalTemplateFunctions = map[string]int{
+		`and`:     1,
+		`or`:      1,
+		`default`: 0,
+	}
+)
+
+type (
+	// templateFunctionCall is a function call found inside a parsed template
+	templateFunctionCall struct {
+		// Name is the function name as used inside the template (can be an alias)
+		Name string
+
+		// Args are the constant arguments of the call, nil for non-constant arguments
+		Args []interface{}
+
+		// Dynamic is true if at least one argument is not a constant (eg. variables or pipelines)
+		Dynamic bool
+
+		// Piped is true if the call receives the result of the previous pipeline command as last argument
+		Piped bool
+
+		// Location is the position of the call inside the template (file:line:col)
+		Location string
+	}
+
+	// templateWalker walks the nodes of a parsed template
+	templateWalker struct {
+		tree     *parse.Tree
+		callback func(call templateFunctionCall)
+
+		// reachableOnly skips bodies of if/else/range/with and arguments of and/or/default which might not be executed,
+		// named templates are only walked if they are called by {{template}}
+		reachableOnly bool
+
+		// templates are the named templates (only used with reachableOnly)
+		templates *textTemplate.Template
+		visited   map[string]bool
+	}
+)
+
+// collectTemplateFunctionCalls walks all parsed templates and returns all function calls
+func collectTemplateFunctionCalls(tmpl *textTemplate.Template) (list []templateFunctionCall) {
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+
+		walker := &templateWalker{tree: t.Tree, callback: func(call templateFunctionCall) {
+			list = append(list, call)
+		}}
+		walker.walkNode(t.Tree.Root)
+	}
+	return
+}
+
+// collectReachableTemplateFunctionCalls returns all function calls which are always executed when tmpl is executed,
+// calls inside if/else/range/with bodies, conditional arguments of and/or/default and inside unused named templates are skipped
+func collectReachableTemplateFunctionCalls(tmpl *textTemplate.Template) (list []templateFunctionCall) {
+	if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+		return
+	}
+
+	walker := &templateWalker{
+		tree: tmpl.Tree,
+		callback: func(call templateFunctionCall) {
+			list = append(list, call)
+		},
+		reachableOnly: true,
+		templates:     tmpl,
+		visited:       map[string]bool{tmpl.Name(): true},
+	}
+	walker.walkNode(tmpl.Tree.Root)
+	return
+}
+
+// walkNode walks all nodes and calls callback for every found function call
+func (w *templateWalker) walkNode(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			w.walkNode(child)
+		}
+	case *parse.ActionNode:
+		w.walkNode(n.Pipe)
+	case *parse.IfNode:
+		w.walkBranchNode(&n.BranchNode)
+	case *parse.RangeNode:
+		w.walkBranchNode(&n.BranchNode)
+	case *parse.WithNode:
+		w.walkBranchNode(&n.BranchNode)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			w.walkNode(n.Pipe)
+		}
+		if w.reachableOnly {
+			w.walkNamedTemplate(n.Name)
+		}
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for i, cmd := range n.Cmds {
+			w.walkCommandNode(cmd, i > 0)
+		}
+	case *parse.ChainNode:
+		w.walkNode(n.Node)
+	}
+}
+
+func (w *templateWalker) walkBranchNode(node *parse.BranchNode) {
+	// the pipeline of if/range/with is always executed, the bodies depend on its result
+	w.walkNode(node.Pipe)
+	if w.reachableOnly {
+		return
+	}
+	w.walkNode(node.List)
+	w.walkNode(node.ElseList)
+}
+
+// walkNamedTemplate walks the named template called by {{template}} (each template only once)
+func (w *templateWalker) walkNamedTemplate(name string) {
+	if w.visited[name] {
+		return
+	}
+	w.visited[name] = true
+
+	t := w.templates.Lookup(name)
+	if t == nil || t.Tree == nil || t.Tree.Root == nil {
+		return
+	}
+
+	tree := w.tree
+	w.tree = t.Tree
+	w.walkNode(t.Tree.Root)
+	w.tree = tree
+}
+
+// walkCommandNode handles one command of a pipeline,
+// commands which are not the first one inside a pipeline are receiving the previous result as last argument
+func (w *templateWalker) walkCommandNode(node *parse.CommandNode, piped bool) {
+	if len(node.Args) == 0 {
+		return
+	}
+
+	identifier, ok := node.Args[0].(*parse.IdentifierNode)
+
+	// nested pipelines and chains inside arguments
+	args := node.Args
+	if ok && w.reachableOnly {
+		if count, conditional := conditionalTemplateFunctions[identifier.Ident]; conditional && len(args) > count+1 {
+			args = args[:count+1]
+		}
+	}
+	for _, arg := range args {
+		w.walkNode(arg)
+	}
+
+	if !ok {
+		return
+	}
+
+	location, _ := w.tree.ErrorContext(node)
+	call := templateFunctionCall{
+		Name:     identifier.Ident,
+		Args:     []interface{}{},
+		Dynamic:  piped,
+		Piped:    piped,
+		Location: location,
+	}
+
+	for _, arg := range node.Args[1:] {
+		if val, ok := templateConstantValue(arg); ok {
+			call.Args = append(call.Args, val)
+		} else {
+			call.Args = append(call.Args, nil)
+			call.Dynamic = true
+		}
+	}
+
+	w.callback(call)
+}
+
+// templateConstantValue returns the value of constant template nodes (strings, numbers and booleans)
+func templateConstantValue(node parse.Node) (interface{}, bool) {
+	switch n := node.(type) {
+	case *parse.StringNode:
+		return n.Text, true
+	case *parse.NumberNode:
+		switch {
+		case n.IsInt:
+			return n.Int64, true
+		case n.IsFloat:
+			return n.Float64, true
+		}
+	case *parse.BoolNode:
+		return n.True, true
+	}
+
+	return nil, false
+}
+
+// canonicalTemplateFunctionName returns the function name without legacy prefix (azureFunc -> azFunc, msGraphFunc -> mgFunc)
+func canonicalTemplateFunctionName(name string) string {
+	switch {
+	case strings.HasPrefix(name, "azure"):
+		return "az" + strings.TrimPrefix(name, "azure")
+	case strings.HasPrefix(name, "msGraph"):
+		return "mg" + strings.TrimPrefix(name, "msGraph")
+	}
+	return name
+}
+
+// callTemplateFunction calls template function with arguments, arguments are converted like in text/template
+func callTemplateFunction(name string, fn interface{}, args []interface{}) (interface{}, error) {
+	fnValue := reflect.ValueOf(fn)
+	fnType := fnValue.Type()
+	if fnType.Kind() != reflect.Func {
+		return nil, fmt.Errorf(`"%v" is not a function`, name)
+	}
+
+	numIn := fnType.NumIn()
+	if fnType.IsVariadic() {
+		if len(args) < numIn-1 {
+			return nil, fmt.Errorf(`wrong number of args for %v: want at least %d got %d`, name, numIn-1, len(args))
+		}
+	} else if len(args) != numIn {
+		return nil, fmt.Errorf(`wrong number of args for %v: want %d got %d`, name, numIn, len(args))
+	}
+
+	argValues := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var argType reflect.Type
+		if fnType.IsVariadic() && i >= numIn-1 {
+			argType = fnType.In(numIn - 1).Elem()
+		} else {
+			argType = fnType.In(i)
+		}
+
+		val, err := convertTemplateArgument(arg, argType)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid argument %d for %v: %w`, i+1, name, err)
+		}
+		argValues[i] = val
+	}
+
+	result := fnValue.Call(argValues)
+	switch len(result) {
+	case 1:
+		return result[0].Interface(), nil
+	case 2:
+		if err, ok := result[1].Interface().(error); ok && err != nil {
+			return result[0].Interface(), err
+		}
+		return result[0].Interface(), nil
+	}
+
+	return nil, nil
+}
+
+func convertTemplateArgument(arg interface{}, argType reflect.Type) (reflect.Value, error) {
+	if arg == nil {
+		switch argType.Kind() {
+		case reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+			return reflect.Zero(argType), nil
+		}
+		return reflect.Value{}, fmt.Errorf(`cannot use nil as %v`, argType)
+	}
+
+	val := reflect.ValueOf(arg)
+	if val.Type().AssignableTo(argType) {
+		return val, nil
+	}
+
+	// numbers are parsed as int64 or float64 by text/template
+	if isNumericKind(val.Kind()) && isNumericKind(argType.Kind()) {
+		return val.Convert(argType), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf(`cannot use %T as %v`, arg, argType)
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
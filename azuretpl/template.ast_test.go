@@ -0,0 +1,120 @@
+package azuretpl
+
+import (
+	"reflect"
+	"testing"
+	textTemplate "text/template"
+)
+
+func parseTestTemplate(t *testing.T, content string) *textTemplate.Template {
+	t.Helper()
+
+	funcMap := textTemplate.FuncMap{
+		"azKeyVaultSecret": func(args ...string) string { return "" },
+		"azSubscription":   func(args ...string) string { return "" },
+		"default":          func(args ...interface{}) interface{} { return nil },
+	}
+
+	tmpl, err := textTemplate.New("test").Funcs(funcMap).Parse(content)
+	if err != nil {
+		t.Fatalf("unable to parse template: %v", err)
+	}
+	return tmpl
+}
+
+func templateFunctionCallNames(list []templateFunctionCall) []string {
+	ret := []string{}
+	for _, call := range list {
+		if call.Name == "azKeyVaultSecret" || call.Name == "azSubscription" {
+			ret = append(ret, call.Name+":"+call.Args[0].(string))
+		}
+	}
+	return ret
+}
+
+func TestCollectReachableTemplateFunctionCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		expected []string
+	}{
+		{
+			name:     "action",
+			template: `{{ azKeyVaultSecret "a" "secret" }}`,
+			expected: []string{"azKeyVaultSecret:a"},
+		},
+		{
+			name:     "if body is skipped, pipeline is used",
+			template: `{{ if azSubscription "cond" }}{{ azKeyVaultSecret "a" "secret" }}{{ else }}{{ azKeyVaultSecret "b" "secret" }}{{ end }}`,
+			expected: []string{"azSubscription:cond"},
+		},
+		{
+			name:     "range and with bodies are skipped",
+			template: `{{ range .List }}{{ azKeyVaultSecret "a" "secret" }}{{ end }}{{ with .Value }}{{ azKeyVaultSecret "b" "secret" }}{{ end }}`,
+			expected: []string{},
+		},
+		{
+			name:     "and/or only use first argument",
+			template: `{{ $x := and (azKeyVaultSecret "a" "secret") (azKeyVaultSecret "b" "secret") }}{{ $y := or .Values.enabled (azKeyVaultSecret "c" "secret") }}`,
+			expected: []string{"azKeyVaultSecret:a"},
+		},
+		{
+			name:     "default arguments are skipped",
+			template: `{{ default (azKeyVaultSecret "a" "secret") .Value }}{{ .Value | default (azKeyVaultSecret "b" "secret") }}`,
+			expected: []string{},
+		},
+		{
+			name:     "named templates are only used if called",
+			template: `{{ define "used" }}{{ azKeyVaultSecret "a" "secret" }}{{ end }}{{ define "unused" }}{{ azKeyVaultSecret "b" "secret" }}{{ end }}{{ template "used" . }}`,
+			expected: []string{"azKeyVaultSecret:a"},
+		},
+		{
+			name:     "recursive named templates",
+			template: `{{ define "loop" }}{{ azKeyVaultSecret "a" "secret" }}{{ template "loop" . }}{{ end }}{{ template "loop" . }}`,
+			expected: []string{"azKeyVaultSecret:a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl := parseTestTemplate(t, test.template)
+			result := templateFunctionCallNames(collectReachableTemplateFunctionCalls(tmpl))
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestCollectTemplateFunctionCalls(t *testing.T) {
+	tmpl := parseTestTemplate(t, `{{ if azSubscription "cond" }}{{ azKeyVaultSecret "a" "secret" }}{{ end }}{{ $x := and .Value (azKeyVaultSecret "b" "secret") }}`)
+
+	result := templateFunctionCallNames(collectTemplateFunctionCalls(tmpl))
+	expected := []string{"azSubscription:cond", "azKeyVaultSecret:a", "azKeyVaultSecret:b"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
+
+func TestTemplateFunctionCallArguments(t *testing.T) {
+	tmpl := parseTestTemplate(t, `{{ azKeyVaultSecret "vault" .Name }}{{ "vault" | azKeyVaultSecret "secret" }}`)
+
+	list := []templateFunctionCall{}
+	for _, call := range collectTemplateFunctionCalls(tmpl) {
+		if call.Name == "azKeyVaultSecret" {
+			list = append(list, call)
+		}
+	}
+
+	if len(list) != 2 {
+		t.Fatalf("expected 2 calls, got %v", len(list))
+	}
+
+	if !reflect.DeepEqual(list[0].Args, []interface{}{"vault", nil}) || !list[0].Dynamic || list[0].Piped {
+		t.Errorf("unexpected call with dynamic argument: %+v", list[0])
+	}
+
+	if !reflect.DeepEqual(list[1].Args, []interface{}{"secret"}) || !list[1].Dynamic || !list[1].Piped {
+		t.Errorf("unexpected piped call: %+v", list[1])
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl"
+)
+
+const (
+	CacheCommandList  = "list"
+	CacheCommandClear = "clear"
+)
+
+// runCacheCommand handles "cache list" and "cache clear"
+func runCacheCommand() {
+	if opts.AzureTpl.Cache.Dir == "" {
+		logger.Error(`no persistent cache directory set, please use --cache.dir or env AZURETPL_CACHE_DIR`)
+		os.Exit(1)
+	}
+
+	if len(opts.Args.Files) != 1 {
+		logger.Error(fmt.Sprintf(`expected one cache command ("%v" or "%v")`, CacheCommandList, CacheCommandClear))
+		os.Exit(1)
+	}
+
+	persistentCache, err := azuretpl.NewPersistentCache(opts.AzureTpl.Cache.Dir, opts.AzureTpl.Cache.EncryptionKey)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	switch opts.Args.Files[0] {
+	case CacheCommandList:
+		list, err := persistentCache.List()
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "FUNCTION\tKEY\tEXPIRES\tSTATUS")
+		for _, entry := range list {
+			key := entry.Key
+			if entry.Encrypted {
+				key = "<encrypted>"
+			}
+
+			status := "valid"
+			if time.Now().After(entry.Expires) {
+				status = "expired"
+			}
+
+			fmt.Fprintf(writer, "%v\t%v\t%v\t%v\n", entry.Function, key, entry.Expires.Format(time.RFC3339), status)
+		}
+		writer.Flush() // nolint: errcheck
+	case CacheCommandClear:
+		count, err := persistentCache.Clear()
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		logger.Info(fmt.Sprintf(`removed %v entries from persistent cache`, count))
+	default:
+		logger.Error(fmt.Sprintf(`invalid cache command "%v", expected "%v" or "%v"`, opts.Args.Files[0], CacheCommandList, CacheCommandClear))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+const (
+	CheckStatusUpToDate = "up-to-date"
+	CheckStatusStale    = "stale"
+	CheckStatusMissing  = "missing"
+)
+
+type (
+	// CheckReport is the machine-readable result of the check command
+	CheckReport struct {
+		Stale bool              `json:"stale"`
+		Files []CheckReportFile `json:"files"`
+	}
+
+	CheckReportFile struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+		Status string `json:"status"`
+	}
+)
+
+// Check compares content with the existing target file
+func (f *TemplateFile) Check(content string) (CheckReportFile, error) {
+	result := CheckReportFile{
+		Source: f.SourceFile,
+		Target: f.TargetFile,
+		Status: CheckStatusUpToDate,
+	}
+
+	currentContent, err := os.ReadFile(f.TargetFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		result.Status = CheckStatusMissing
+	case err != nil:
+		return result, fmt.Errorf(`unable to read target file "%v": %w`, f.TargetFile, err)
+	case string(currentContent) != content:
+		result.Status = CheckStatusStale
+	}
+
+	if result.Status != CheckStatusUpToDate {
+		f.Logger.Warn(`target file is not up to date`, slog.String("status", result.Status))
+	} else {
+		f.Logger.Info(`target file is up to date`)
+	}
+
+	return result, nil
+}
+
+func (r *CheckReport) add(file CheckReportFile) {
+	r.Files = append(r.Files, file)
+	if file.Status != CheckStatusUpToDate {
+		r.Stale = true
+	}
+}
+
+func (r *CheckReport) write(w io.Writer) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf(`unable to marshal check report: %w`, err)
+	}
+
+	_, err = fmt.Fprintln(w, string(content))
+	return err
+}
@@ -27,6 +27,19 @@ type (
 		Debug  bool `long:"debug"   env:"HELMHELM_DEBUG_DEBUG"  description:"debug run, print generated content to stdout (WARNING: can expose secrets!)"`
 		Stdout bool `long:"stdout"  env:"AZURETPL_STDOUT"       description:"Print parsed content to stdout instead of file (logs will be written to stderr)"`
 
+		Parallel int `long:"parallel"  env:"AZURETPL_PARALLEL"  description:"number of template files which are processed in parallel (target files are written after all files are rendered if greater than 1)" default:"1"`
+
+		Report struct {
+			Path   string `long:"report"         env:"AZURETPL_REPORT"         description:"write report of template errors to this file"`
+			Format string `long:"report.format"  env:"AZURETPL_REPORT_FORMAT"  description:"format of report file" choice:"json" choice:"sarif" default:"json"` // nolint:staticcheck // multiple choices are ok
+		}
+
+		Rbac struct {
+			RoleName         string   `long:"rbac.rolename"         env:"AZURETPL_RBAC_ROLENAME"         description:"name of the generated role definition (rbac-plan)" default:"helm-azure-tpl"`
+			AssignableScopes []string `long:"rbac.assignablescope"  env:"AZURETPL_RBAC_ASSIGNABLESCOPE"  env-delim:"," description:"assignable scopes of the generated role definition, detected from used resources if empty (rbac-plan)"`
+			Profile          string   `long:"rbac.profile"          env:"AZURETPL_RBAC_PROFILE"          description:"only use template function calls of this auth profile (azWithProfile), calls without profile if empty (rbac-plan)"`
+		}
+
 		Template struct {
 			BasePath *string `long:"template.basepath"  env:"AZURETPL_TEMPLATE_BASEPATH"  description:"sets custom base path (if empty, base path is set by base directory for each file. will be appended to all root paths inside templates)"`
 		}
@@ -40,7 +53,7 @@ type (
 		AzureTpl models.Opts
 
 		Args struct {
-			Command string   `positional-arg-name:"command" description:"specifies what to do (help, version, lint, apply)" choice:"help" choice:"version" choice:"lint" choice:"apply" required:"yes"` // nolint:staticcheck
+			Command string   `positional-arg-name:"command" description:"specifies what to do (help, version, lint, apply, diff, check, update-lock, deps, rbac-plan, cache)" choice:"help" choice:"version" choice:"lint" choice:"apply" choice:"diff" choice:"check" choice:"update-lock" choice:"deps" choice:"rbac-plan" choice:"cache" required:"yes"` // nolint:staticcheck
 			Files   []string `positional-arg-name:"files" description:"list of files to process (will overwrite files, different target file can be specified as sourcefile:targetfile)"`
 		} `positional-args:"yes" `
 	}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl"
+)
+
+type (
+	// DepsReport is the machine-readable result of the deps command
+	DepsReport struct {
+		Templates []DepsReportTemplate `json:"templates"`
+	}
+
+	DepsReportTemplate struct {
+		Source       string                        `json:"source"`
+		Dependencies []azuretpl.TemplateDependency `json:"dependencies"`
+	}
+)
+
+// runDepsCommand prints all Azure template function calls of the template files (without processing the templates)
+func runDepsCommand() {
+	if len(opts.Args.Files) == 0 {
+		logger.Error(`no files specified as arguments`)
+		os.Exit(1)
+	}
+
+	depsReport := DepsReport{Templates: []DepsReportTemplate{}}
+	for _, templateFile := range buildSourceTargetList() {
+		dependencies, err := templateFile.Dependencies()
+		if err != nil {
+			templateFile.Logger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		depsReport.Templates = append(depsReport.Templates, DepsReportTemplate{
+			Source:       templateFile.SourceFile,
+			Dependencies: dependencies,
+		})
+	}
+
+	if err := depsReport.write(os.Stdout); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// Dependencies returns all Azure template function calls found by static analysis of the template file
+func (f *TemplateFile) Dependencies() ([]azuretpl.TemplateDependency, error) {
+	f.Logger.Info(`analyzing file`)
+
+	azureTemplate, err := f.newExecutor()
+	if err != nil {
+		return nil, err
+	}
+
+	return azureTemplate.Dependencies(f.SourceFile)
+}
+
+func (r *DepsReport) write(w io.Writer) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf(`unable to marshal deps report: %w`, err)
+	}
+
+	_, err = fmt.Fprintln(w, string(content))
+	return err
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	DiffContextLines = 3
+
+	// DiffMaxLcsCells limits the size of the lcs table (changed old lines * changed new lines, 4 bytes per cell),
+	// larger changes are shown as replacement of all changed lines
+	DiffMaxLcsCells = 16 * 1024 * 1024
+)
+
+var (
+	// diffKeyValueRegexp matches yaml key/value lines (eg. "  password: value" or "- key: value")
+	diffKeyValueRegexp = regexp.MustCompile(`^(\s*(?:-\s+)?[^\s:#][^:]*:\s*)(.*)$`)
+
+	// diffBlockScalarRegexp matches yaml block scalar indicators (eg. "|" or ">-")
+	diffBlockScalarRegexp = regexp.MustCompile(`^[|>][-+0-9]*$`)
+
+	// diffSecretKeyRegexp matches lines with keys which usually contain secrets
+	diffSecretKeyRegexp = regexp.MustCompile(`(?i)^\s*(?:-\s+)?["']?[\w.-]*(?:password|passwd|secret|token|credential|connectionstring|privatekey|private_key|apikey|api_key|accesskey|access_key|primarykey|secondarykey|tls\.key)[\w.-]*["']?\s*[:=]`)
+)
+
+type (
+	diffLine struct {
+		op   byte
+		text string
+	}
+)
+
+// unifiedDiff returns unified diff between oldContent and newContent (empty if both are equal),
+// if secretMask is set lines which could contain secrets are masked (see maskChangedSecretLines)
+func unifiedDiff(oldName, newName, oldContent, newContent, secretMask string, maskAllChanges bool) string {
+	if oldContent == newContent {
+		return ""
+	}
+
+	lines := diffLines(splitDiffLines(oldContent), splitDiffLines(newContent))
+	if secretMask != "" {
+		maskChangedSecretLines(lines, secretMask, maskAllChanges)
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n", oldName)
+	fmt.Fprintf(&buf, "+++ %s\n", newName)
+
+	// line numbers (0-based) of the current position in old and new content
+	oldLine, newLine := 0, 0
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// build hunk including context lines before and after changes
+		start := max(i-DiffContextLines, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+
+			// find next change, merge into this hunk if context lines overlap
+			next := end
+			for next < len(lines) && lines[next].op == ' ' {
+				next++
+			}
+			if next == len(lines) || next-end > 2*DiffContextLines {
+				end = min(end+DiffContextLines, len(lines))
+				break
+			}
+			end = next
+		}
+
+		hunkOldStart := oldLine - (i - start)
+		hunkNewStart := newLine - (i - start)
+		hunkOldLen, hunkNewLen := 0, 0
+		var hunk strings.Builder
+		for _, line := range lines[start:end] {
+			switch line.op {
+			case ' ':
+				hunkOldLen++
+				hunkNewLen++
+			case '-':
+				hunkOldLen++
+			case '+':
+				hunkNewLen++
+			}
+			fmt.Fprintf(&hunk, "%c%s\n", line.op, line.text)
+		}
+
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", diffRange(hunkOldStart, hunkOldLen), diffRange(hunkNewStart, hunkNewLen))
+		buf.WriteString(hunk.String())
+
+		// advance position to end of hunk
+		for _, line := range lines[i:end] {
+			switch line.op {
+			case ' ':
+				oldLine++
+				newLine++
+			case '-':
+				oldLine++
+			case '+':
+				newLine++
+			}
+		}
+		i = end
+	}
+
+	return buf.String()
+}
+
+// maskChangedSecretLines masks changed lines which could contain secrets: the old value is unknown (eg. previous secret version)
+// and secrets could be transformed inside the template (eg. concatenated before b64enc), so masking fails closed:
+// all changed lines are masked if maskAllChanges is set (file contains secrets), otherwise lines with secret-like keys
+func maskChangedSecretLines(lines []diffLine, secretMask string, maskAllChanges bool) {
+	for i := range lines {
+		line := &lines[i]
+
+		// secret-like keys are also masked in context lines
+		if (line.op != ' ' && maskAllChanges) || diffSecretKeyRegexp.MatchString(line.text) {
+			line.text = maskDiffLine(line.text, secretMask)
+		}
+	}
+}
+
+// maskDiffLine masks the value of a line, yaml keys (eg. "  password: value") are kept
+func maskDiffLine(text, secretMask string) string {
+	if match := diffKeyValueRegexp.FindStringSubmatch(text); match != nil {
+		if match[2] == "" || match[2] == secretMask || diffBlockScalarRegexp.MatchString(match[2]) {
+			// no value or block scalar, the following lines contain the value
+			return text
+		}
+		return match[1] + secretMask
+	}
+
+	indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+	if strings.TrimSpace(text) == "" {
+		return text
+	}
+	return indent + secretMask
+}
+
+// diffRange formats hunk range (line numbers are 1-based, empty ranges are pointing to the line before)
+func diffRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+func splitDiffLines(content string) []string {
+	if content == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// diffLines calculates the line based edit script using the longest common subsequence,
+// if the changed part is too large (see DiffMaxLcsCells) all changed lines are replaced
+func diffLines(oldLines, newLines []string) []diffLine {
+	// skip common prefix and suffix to keep the lcs table small
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix && oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	a := oldLines[prefix : len(oldLines)-suffix]
+	b := newLines[prefix : len(newLines)-suffix]
+
+	ret := make([]diffLine, 0, len(oldLines)+len(newLines))
+	for _, line := range oldLines[:prefix] {
+		ret = append(ret, diffLine{' ', line})
+	}
+
+	i, j := 0, 0
+	if int64(len(a))*int64(len(b)) <= DiffMaxLcsCells {
+		// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+		lcs := make([][]int32, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+
+		for i < len(a) && j < len(b) {
+			switch {
+			case a[i] == b[j]:
+				ret = append(ret, diffLine{' ', a[i]})
+				i++
+				j++
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				ret = append(ret, diffLine{'-', a[i]})
+				i++
+			default:
+				ret = append(ret, diffLine{'+', b[j]})
+				j++
+			}
+		}
+	}
+
+	// remaining lines (or all changed lines if lcs table is too large)
+	for ; i < len(a); i++ {
+		ret = append(ret, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ret = append(ret, diffLine{'+', b[j]})
+	}
+
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		ret = append(ret, diffLine{' ', line})
+	}
+
+	return ret
+}
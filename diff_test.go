@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func diffLinesString(lines []diffLine) []string {
+	ret := []string{}
+	for _, line := range lines {
+		ret = append(ret, string(line.op)+line.text)
+	}
+	return ret
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      []string
+		new      []string
+		expected []string
+	}{
+		{
+			name:     "equal",
+			old:      []string{"a", "b"},
+			new:      []string{"a", "b"},
+			expected: []string{" a", " b"},
+		},
+		{
+			name:     "changed line",
+			old:      []string{"a", "b", "c"},
+			new:      []string{"a", "x", "c"},
+			expected: []string{" a", "-b", "+x", " c"},
+		},
+		{
+			name:     "added and removed lines",
+			old:      []string{"a", "b", "c", "d"},
+			new:      []string{"b", "c", "e", "d", "f"},
+			expected: []string{"-a", " b", " c", "+e", " d", "+f"},
+		},
+		{
+			name:     "empty old content",
+			old:      []string{},
+			new:      []string{"a"},
+			expected: []string{"+a"},
+		},
+		{
+			name:     "empty new content",
+			old:      []string{"a"},
+			new:      []string{},
+			expected: []string{"-a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := diffLinesString(diffLines(test.old, test.new))
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestDiffLinesLargeChange(t *testing.T) {
+	size := 5000
+	oldLines := []string{"header"}
+	newLines := []string{"header"}
+	for i := 0; i < size; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("old %d", i))
+		newLines = append(newLines, fmt.Sprintf("new %d", i))
+	}
+	oldLines = append(oldLines, "footer")
+	newLines = append(newLines, "footer")
+
+	result := diffLines(oldLines, newLines)
+	if len(result) != 2*size+2 {
+		t.Fatalf("expected %v lines, got %v", 2*size+2, len(result))
+	}
+
+	if result[0].op != ' ' || result[len(result)-1].op != ' ' {
+		t.Errorf("expected common prefix and suffix as context lines")
+	}
+
+	for i, line := range result[1 : len(result)-1] {
+		expectedOp := byte('-')
+		if i >= size {
+			expectedOp = '+'
+		}
+		if line.op != expectedOp {
+			t.Fatalf("expected %c for line %v, got %c", expectedOp, i, line.op)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	oldContent := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	newContent := "a\nb\nc\nd\nE\nf\ng\nh\ni\nj\nk\n"
+
+	expected := strings.Join([]string{
+		"--- old",
+		"+++ new",
+		"@@ -2,9 +2,10 @@",
+		" b",
+		" c",
+		" d",
+		"-e",
+		"+E",
+		" f",
+		" g",
+		" h",
+		" i",
+		" j",
+		"+k",
+		"",
+	}, "\n")
+
+	if result := unifiedDiff("old", "new", oldContent, newContent, "", false); result != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+	}
+
+	if result := unifiedDiff("old", "new", oldContent, oldContent, "", false); result != "" {
+		t.Errorf("expected empty diff for equal content, got:\n%s", result)
+	}
+}
+
+func TestMaskChangedSecretLines(t *testing.T) {
+	tests := []struct {
+		name           string
+		lines          []diffLine
+		maskAllChanges bool
+		expected       []string
+	}{
+		{
+			name:     "secret-like keys",
+			lines:    []diffLine{{' ', "  password: foo"}, {'-', "  user: old"}, {'+', "  user: new"}, {'+', "  apiKey: bar"}},
+			expected: []string{"   password: ***", "-  user: old", "+  user: new", "+  apiKey: ***"},
+		},
+		{
+			name:           "all changes",
+			lines:          []diffLine{{' ', "  user: foo"}, {'-', "  value: old"}, {'+', "  - item"}, {'+', "  data: |"}},
+			maskAllChanges: true,
+			expected:       []string{"   user: foo", "-  value: ***", "+  ***", "+  data: |"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			maskChangedSecretLines(test.lines, "***", test.maskAllChanges)
+			if result := diffLinesString(test.lines); !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl"
+)
+
+type (
+	// UpdateLockReport is the machine-readable result of the update-lock command
+	UpdateLockReport struct {
+		Changed bool                      `json:"changed"`
+		Changes []azuretpl.LockFileChange `json:"changes"`
+	}
+)
+
+// updateLockFile writes the versions used by the processed templates to the lock file
+func updateLockFile() (*UpdateLockReport, error) {
+	changes, err := azuretpl.UpdateLockFile(opts.AzureTpl.Lock.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		logger.Info(
+			fmt.Sprintf(`lock file entry %v`, change.Status),
+			slog.String("template", change.Template),
+			slog.String("type", change.Type),
+			slog.String("id", change.Id),
+			slog.String("old", change.Old),
+			slog.String("new", change.New),
+		)
+	}
+
+	logger.Info(`lock file updated`, slog.String("path", opts.AzureTpl.Lock.Path), slog.Int("changes", len(changes)))
+
+	return &UpdateLockReport{
+		Changed: len(changes) > 0,
+		Changes: changes,
+	}, nil
+}
+
+func (r *UpdateLockReport) write(w io.Writer) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf(`unable to marshal update-lock report: %w`, err)
+	}
+
+	_, err = fmt.Fprintln(w, string(content))
+	return err
+}
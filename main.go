@@ -1,17 +1,17 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
-	"os/exec"
 	"time"
 
 	"github.com/jessevdk/go-flags"
 	"github.com/webdevops/go-common/azuresdk/azidentity"
 
+	"github.com/webdevops/helm-azure-tpl/azuretpl"
 	"github.com/webdevops/helm-azure-tpl/config"
 )
 
@@ -73,26 +73,23 @@ func initArgparser() {
 }
 
 func fetchAzAccountInfo() {
-	cmd := exec.Command("az", "account", "show", "-o", "json")
-	cmd.Stderr = os.Stderr
+	// account information is detected using the auth method (Azure CLI or credential),
+	// results are not cached or recorded as fixtures as the current login could have changed (eg. az login or az account set)
+	azureTemplate := azuretpl.New(context.Background(), opts.AzureTpl, logger)
+	azureTemplate.SetUserAgent(UserAgent + gitTag)
 
-	accountInfo, err := cmd.Output()
+	accountInfo, err := azureTemplate.AccountInfo()
 	if err != nil {
-		logger.Error(`unable to detect Azure TenantID via 'az account show'`, slog.Any("error", err))
-		os.Exit(1)
-	}
-
-	err = json.Unmarshal(accountInfo, &azAccountInfo)
-	if err != nil {
-		logger.Error(`unable to parse 'az account show' output`, slog.Any("error", err))
+		logger.Error(`unable to detect Azure account information`, slog.String("authMethod", opts.AzureTpl.Auth.Method), slog.Any("error", err))
 		os.Exit(1)
 	}
+	azAccountInfo = accountInfo
 
 	// auto set azure tenant id
 	if opts.Azure.Environment == nil || *opts.Azure.Environment == "" {
 		// autodetect tenant
 		if val, ok := azAccountInfo["environmentName"].(string); ok {
-			logger.Info(`detected Azure Environment from 'az account show'`, slog.String("azureEnvironment", val))
+			logger.Info(`detected Azure Environment from account information`, slog.String("azureEnvironment", val))
 			opts.Azure.Environment = &val
 		}
 	}
@@ -101,7 +98,7 @@ func fetchAzAccountInfo() {
 	if opts.Azure.Tenant == nil || *opts.Azure.Tenant == "" {
 		// autodetect tenant
 		if val, ok := azAccountInfo["tenantId"].(string); ok {
-			logger.Info(`detected Azure TenantID from 'az account show'`, slog.String("azureTenant", val))
+			logger.Info(`detected Azure TenantID from account information`, slog.String("azureTenant", val))
 			opts.Azure.Tenant = &val
 		}
 	}
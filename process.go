@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	yaml "gopkg.in/yaml.v3"
@@ -23,6 +25,19 @@ const (
 	CommandVersion = "version"
 	CommandLint    = "lint"
 	CommandProcess = "apply"
+	CommandCache   = "cache"
+	CommandDiff    = "diff"
+	CommandCheck   = "check"
+	CommandDeps    = "deps"
+	CommandRbac    = "rbac-plan"
+
+	CommandUpdateLock = "update-lock"
+
+	// ExitCodeDiff is used by the diff command if differences are found
+	ExitCodeDiff = 2
+
+	// ExitCodeStale is used by the check command if target files are not up to date
+	ExitCodeStale = 3
 )
 
 var (
@@ -34,7 +49,7 @@ func run() {
 	// init template data
 	templateData = make(map[string]interface{})
 
-	lintMode := false
+	lintMode = false
 	switch opts.Args.Command {
 	case CommandHelp:
 		argparser.WriteHelp(os.Stdout)
@@ -55,10 +70,19 @@ func run() {
 		version, _ := json.Marshal(versionPayload) // nolint: errcheck
 		fmt.Println(string(version))
 		os.Exit(0)
+	case CommandCache:
+		runCacheCommand()
+		os.Exit(0)
+	case CommandDeps:
+		runDepsCommand()
+		os.Exit(0)
+	case CommandRbac:
+		runRbacPlanCommand()
+		os.Exit(0)
 	case CommandLint:
 		lintMode = true
 		fallthrough
-	case CommandProcess:
+	case CommandProcess, CommandDiff, CommandCheck, CommandUpdateLock:
 		printAppHeader()
 		initSystem()
 
@@ -77,7 +101,27 @@ func run() {
 		}
 		templateFileList := buildSourceTargetList()
 
-		if !lintMode {
+		if opts.AzureTpl.Fixture.Replay != "" {
+			logger.Info("enabling replay mode, using recorded fixtures instead of Azure API calls", slog.String("path", opts.AzureTpl.Fixture.Replay))
+			if err := azuretpl.LoadFixtures(opts.AzureTpl.Fixture.Replay); err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if opts.Args.Command == CommandUpdateLock {
+			// current versions are needed, cached results could be outdated
+			logger.Info("updating lock file, persistent cache is not used", slog.String("path", opts.AzureTpl.Lock.Path))
+			opts.AzureTpl.Cache.Dir = ""
+		} else if opts.AzureTpl.Lock.Locked {
+			logger.Info("enabling locked mode, using pinned versions of lock file", slog.String("path", opts.AzureTpl.Lock.Path))
+			if err := azuretpl.LoadLockFile(opts.AzureTpl.Lock.Path); err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if !lintMode && opts.AzureTpl.Fixture.Replay == "" {
 			logger.Info("detecting Azure account information")
 			fetchAzAccountInfo()
 
@@ -87,17 +131,76 @@ func run() {
 			}
 		}
 
-		for _, templateFile := range templateFileList {
-			if lintMode {
-				templateFile.Lint()
-			} else {
-				templateFile.Apply()
+		var (
+			handler     func(templateFile *TemplateFile, content string) error
+			diffFound   bool
+			checkReport = CheckReport{Files: []CheckReportFile{}}
+		)
+		switch opts.Args.Command {
+		case CommandProcess:
+			handler = (*TemplateFile).Apply
+		case CommandDiff:
+			handler = func(templateFile *TemplateFile, content string) error {
+				changed, err := templateFile.Diff(content)
+				diffFound = diffFound || changed
+				return err
+			}
+		case CommandCheck:
+			handler = func(templateFile *TemplateFile, content string) error {
+				result, err := templateFile.Check(content)
+				checkReport.add(result)
+				return err
+			}
+		}
+
+		err := processTemplateFiles(templateFileList, handler)
+		writeReport()
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		var lockReport *UpdateLockReport
+		if opts.Args.Command == CommandUpdateLock {
+			if lockReport, err = updateLockFile(); err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if opts.AzureTpl.Fixture.Record != "" {
+			logger.Info("writing recorded fixtures", slog.String("path", opts.AzureTpl.Fixture.Record))
+			if err := azuretpl.SaveFixtures(opts.AzureTpl.Fixture.Record); err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
 			}
 		}
 
 		azuretpl.PostSummary(logger, opts)
 
 		logger.With(slog.Duration("duration", time.Since(startTime))).Info("finished")
+
+		if diffFound {
+			os.Exit(ExitCodeDiff)
+		}
+
+		if opts.Args.Command == CommandCheck {
+			if err := checkReport.write(os.Stdout); err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+
+			if checkReport.Stale {
+				os.Exit(ExitCodeStale)
+			}
+		}
+
+		if lockReport != nil {
+			if err := lockReport.write(os.Stdout); err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+		}
 	default:
 		fmt.Printf("invalid command '%v'\n", opts.Args.Command)
 		fmt.Println()
@@ -106,6 +209,129 @@ func run() {
 	}
 }
 
+// processTemplateFiles renders template files (up to --parallel files concurrently)
+// and passes the results in order of the arguments to handler (not used in lint mode)
+func processTemplateFiles(templateFileList []TemplateFile, handler func(templateFile *TemplateFile, content string) error) error {
+	// files are rendered and handled one by one,
+	// so templates can read target files of previous templates (eg. using filesGet or include)
+	if opts.Parallel <= 1 {
+		return processTemplateFilesSequential(templateFileList, handler)
+	}
+
+	contentList := make([]string, len(templateFileList))
+	errList := make([]error, len(templateFileList))
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+	)
+	semaphore := make(chan struct{}, max(opts.Parallel, 1))
+
+	for i, templateFile := range templateFileList {
+		semaphore <- struct{}{}
+
+		// do not start further files after first error
+		if failed.Load() {
+			<-semaphore
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			if lintMode {
+				errList[i] = templateFile.Lint()
+			} else {
+				contentList[i], errList[i] = templateFile.Render()
+			}
+
+			// lint all files to report all findings
+			if errList[i] != nil && !lintMode {
+				failed.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+
+	failedFiles := []string{}
+	for i, templateFile := range templateFileList {
+		if errList[i] == nil {
+			continue
+		}
+
+		failedFiles = append(failedFiles, templateFile.SourceFile)
+		reportTemplateFileError(templateFile, errList[i])
+	}
+
+	if lintMode && len(failedFiles) > 0 {
+		return fmt.Errorf(`lint failed for templates "%v"`, strings.Join(failedFiles, `", "`))
+	}
+
+	for i, templateFile := range templateFileList {
+		if errList[i] != nil {
+			return fmt.Errorf(`unable to process template "%v"`, templateFile.SourceFile)
+		}
+
+		if lintMode || handler == nil {
+			continue
+		}
+
+		if err := handler(&templateFile, contentList[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processTemplateFilesSequential renders template files one by one and passes each result to handler
+// before the next file is rendered (not used in lint mode)
+func processTemplateFilesSequential(templateFileList []TemplateFile, handler func(templateFile *TemplateFile, content string) error) error {
+	failedFiles := []string{}
+	for _, templateFile := range templateFileList {
+		if lintMode {
+			// lint all files to report all findings
+			if err := templateFile.Lint(); err != nil {
+				failedFiles = append(failedFiles, templateFile.SourceFile)
+				reportTemplateFileError(templateFile, err)
+			}
+			continue
+		}
+
+		content, err := templateFile.Render()
+		if err != nil {
+			reportTemplateFileError(templateFile, err)
+			return fmt.Errorf(`unable to process template "%v"`, templateFile.SourceFile)
+		}
+
+		if handler == nil {
+			continue
+		}
+
+		if err := handler(&templateFile, content); err != nil {
+			return err
+		}
+	}
+
+	if len(failedFiles) > 0 {
+		return fmt.Errorf(`lint failed for templates "%v"`, strings.Join(failedFiles, `", "`))
+	}
+
+	return nil
+}
+
+// reportTemplateFileError logs and reports the error of a template file
+func reportTemplateFileError(templateFile TemplateFile, err error) {
+	if errors.Is(err, errLintFailed) || errors.Is(err, azuretpl.ErrKeyVaultPolicyViolated) {
+		// findings are already logged and reported
+		return
+	}
+	templateFile.Logger.Error(err.Error())
+	report.addError(templateFile, err)
+}
+
 func printAppHeader() {
 	logger.Info(fmt.Sprintf("%v v%s (%s; %s; by %v at %v)", argparser.Name, gitTag, gitCommit, runtime.Version(), Author, buildDate))
 	logger.Info(string(opts.GetJson()))
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl"
+)
+
+const (
+	RbacUnresolvedReasonDynamicResource  = "dynamicResource"
+	RbacUnresolvedReasonDynamicReference = "dynamicReference"
+	RbacUnresolvedReasonUnknownType      = "unknownResourceType"
+)
+
+type (
+	// RbacPlan is the machine-readable result of the rbac-plan command
+	RbacPlan struct {
+		RoleDefinition RbacRoleDefinition `json:"roleDefinition"`
+
+		// Scopes are the Azure resources or scopes which are accessed by the templates with their control plane permissions
+		Scopes []RbacPlanScope `json:"scopes"`
+
+		// DataPlaneHosts are the data plane hosts (eg. KeyVault or AppConfig urls) which are accessed by the templates
+		// with their data plane permissions, role assignments need the resource id of the host
+		DataPlaneHosts []RbacPlanDataPlaneHost `json:"dataPlaneHosts"`
+
+		// GraphPermissions are the MsGraph application permissions (not part of role definitions)
+		GraphPermissions []string `json:"graphPermissions"`
+
+		// Unresolved are the calls with dynamic resources (or resolving references to other resources),
+		// their permissions are included in the role definition but their scope is only known when processing the templates,
+		// read actions of unknown resource types are not included
+		Unresolved []RbacPlanUnresolved `json:"unresolved"`
+	}
+
+	// RbacRoleDefinition is the Azure custom role definition (format of "az role definition create")
+	RbacRoleDefinition struct {
+		Name             string   `json:"Name"`
+		IsCustom         bool     `json:"IsCustom"`
+		Description      string   `json:"Description"`
+		Actions          []string `json:"Actions"`
+		NotActions       []string `json:"NotActions"`
+		DataActions      []string `json:"DataActions"`
+		NotDataActions   []string `json:"NotDataActions"`
+		AssignableScopes []string `json:"AssignableScopes"`
+	}
+
+	RbacPlanScope struct {
+		Scope   string   `json:"scope"`
+		Actions []string `json:"actions"`
+	}
+
+	RbacPlanDataPlaneHost struct {
+		Host        string   `json:"host"`
+		DataActions []string `json:"dataActions"`
+	}
+
+	RbacPlanUnresolved struct {
+		Function string                    `json:"function"`
+		Location azuretpl.TemplateLocation `json:"location"`
+		Reason   string                    `json:"reason"`
+	}
+
+	// rbacActionSet collects unique actions (or scopes)
+	rbacActionSet map[string]bool
+
+	// rbacResourceActions collects unique actions per resource
+	rbacResourceActions map[string]rbacActionSet
+)
+
+// runRbacPlanCommand prints the least-privilege role definition for the Azure template function calls of the template files
+func runRbacPlanCommand() {
+	if len(opts.Args.Files) == 0 {
+		logger.Error(`no files specified as arguments`)
+		os.Exit(1)
+	}
+
+	dependencies := []azuretpl.TemplateDependency{}
+	for _, templateFile := range buildSourceTargetList() {
+		list, err := templateFile.Dependencies()
+		if err != nil {
+			templateFile.Logger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		// every auth profile is a different identity and needs its own role assignment
+		for _, dependency := range list {
+			if dependency.Profile == opts.Rbac.Profile {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+
+	plan := buildRbacPlan(dependencies)
+	for _, unresolved := range plan.Unresolved {
+		switch unresolved.Reason {
+		case RbacUnresolvedReasonDynamicReference:
+			logger.Warn(`template function call resolves references to other resources (eg. KeyVault references), please check assignable scopes`, slog.String("function", unresolved.Function), slog.String("location", unresolved.Location.String()))
+		case RbacUnresolvedReasonUnknownType:
+			logger.Warn(`resource type of template function call is unknown, please add its read action to the role definition`, slog.String("function", unresolved.Function), slog.String("location", unresolved.Location.String()))
+		default:
+			logger.Warn(`scope of template function call is dynamic, please check assignable scopes`, slog.String("function", unresolved.Function), slog.String("location", unresolved.Location.String()))
+		}
+	}
+
+	if len(plan.RoleDefinition.AssignableScopes) == 0 {
+		logger.Error(`no assignable scopes found, please set them with --rbac.assignablescope`)
+		os.Exit(1)
+	}
+
+	if err := plan.write(os.Stdout); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// buildRbacPlan builds role definition, scopes and data plane hosts from the dependencies
+func buildRbacPlan(dependencies []azuretpl.TemplateDependency) RbacPlan {
+	actions := rbacActionSet{}
+	dataActions := rbacActionSet{}
+	graphPermissions := rbacActionSet{}
+	assignableScopes := rbacActionSet{}
+
+	// control plane permissions are assigned on Azure resource ids (scopes),
+	// data plane permissions on the resource of the host (eg. KeyVault url)
+	scopeActions := rbacResourceActions{}
+	hostDataActions := rbacResourceActions{}
+
+	plan := RbacPlan{
+		Unresolved: []RbacPlanUnresolved{},
+	}
+
+	for _, dependency := range dependencies {
+		scoped := false
+		unknownType := false
+		for _, permission := range dependency.Permissions {
+			if permission.Action == azuretpl.PermissionResourceTypeRead {
+				// read action depends on the resource type, only known when processing the templates
+				unknownType = true
+				continue
+			}
+
+			switch permission.Plane {
+			case azuretpl.PermissionPlaneGraph:
+				graphPermissions[permission.Action] = true
+				continue
+			case azuretpl.PermissionPlaneData:
+				dataActions[permission.Action] = true
+				hostDataActions.add(dependency.Resource, permission.Action)
+			default:
+				actions[permission.Action] = true
+				scopeActions.add(dependency.Resource, permission.Action)
+			}
+			scoped = true
+		}
+
+		if scoped || unknownType {
+			if dependency.Resource == "" {
+				plan.Unresolved = append(plan.Unresolved, RbacPlanUnresolved{
+					Function: dependency.Function,
+					Location: dependency.Location,
+					Reason:   RbacUnresolvedReasonDynamicResource,
+				})
+			} else {
+				if unknownType {
+					plan.Unresolved = append(plan.Unresolved, RbacPlanUnresolved{
+						Function: dependency.Function,
+						Location: dependency.Location,
+						Reason:   RbacUnresolvedReasonUnknownType,
+					})
+				}
+
+				if scope := rbacAssignableScope(dependency.Resource); scope != "" {
+					assignableScopes[scope] = true
+				}
+			}
+		}
+
+		// referenced resources (eg. KeyVault references of AppConfig settings) are only known when processing the templates
+		if len(dependency.DynamicPermissions) > 0 {
+			for _, permission := range dependency.DynamicPermissions {
+				if permission.Plane == azuretpl.PermissionPlaneData {
+					dataActions[permission.Action] = true
+				} else {
+					actions[permission.Action] = true
+				}
+			}
+
+			plan.Unresolved = append(plan.Unresolved, RbacPlanUnresolved{
+				Function: dependency.Function,
+				Location: dependency.Location,
+				Reason:   RbacUnresolvedReasonDynamicReference,
+			})
+		}
+	}
+
+	// explicit assignable scopes are replacing the detected ones
+	if len(opts.Rbac.AssignableScopes) > 0 {
+		assignableScopes = rbacActionSet{}
+		for _, scope := range opts.Rbac.AssignableScopes {
+			assignableScopes[scope] = true
+		}
+	}
+
+	plan.RoleDefinition = RbacRoleDefinition{
+		Name:             opts.Rbac.RoleName,
+		IsCustom:         true,
+		Description:      "Permissions required by helm-azure-tpl templates",
+		Actions:          actions.list(),
+		NotActions:       []string{},
+		DataActions:      dataActions.list(),
+		NotDataActions:   []string{},
+		AssignableScopes: assignableScopes.list(),
+	}
+	plan.GraphPermissions = graphPermissions.list()
+
+	plan.Scopes = []RbacPlanScope{}
+	for _, scope := range scopeActions.resources() {
+		plan.Scopes = append(plan.Scopes, RbacPlanScope{
+			Scope:   scope,
+			Actions: scopeActions[scope].list(),
+		})
+	}
+
+	plan.DataPlaneHosts = []RbacPlanDataPlaneHost{}
+	for _, host := range hostDataActions.resources() {
+		plan.DataPlaneHosts = append(plan.DataPlaneHosts, RbacPlanDataPlaneHost{
+			Host:        host,
+			DataActions: hostDataActions[host].list(),
+		})
+	}
+
+	return plan
+}
+
+// rbacAssignableScope returns the subscription or management group of an Azure resource id
+// (empty for data plane urls, eg. KeyVault urls, as their subscription is unknown)
+func rbacAssignableScope(resource string) string {
+	parts := strings.Split(strings.Trim(resource, "/"), "/")
+	if len(parts) < 2 || !strings.HasPrefix(resource, "/") {
+		return ""
+	}
+
+	switch {
+	case strings.EqualFold(parts[0], "subscriptions"):
+		return "/subscriptions/" + parts[1]
+	case len(parts) >= 4 && strings.EqualFold(parts[0], "providers") && strings.EqualFold(parts[1], "Microsoft.Management") && strings.EqualFold(parts[2], "managementGroups"):
+		return "/providers/Microsoft.Management/managementGroups/" + parts[3]
+	}
+
+	return ""
+}
+
+func (r rbacResourceActions) add(resource, action string) {
+	if resource == "" {
+		return
+	}
+
+	if _, ok := r[resource]; !ok {
+		r[resource] = rbacActionSet{}
+	}
+	r[resource][action] = true
+}
+
+func (r rbacResourceActions) resources() []string {
+	ret := make([]string, 0, len(r))
+	for resource := range r {
+		ret = append(ret, resource)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (s rbacActionSet) list() []string {
+	ret := make([]string, 0, len(s))
+	for action := range s {
+		ret = append(ret, action)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (p *RbacPlan) write(w io.Writer) error {
+	content, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf(`unable to marshal rbac plan: %w`, err)
+	}
+
+	_, err = fmt.Fprintln(w, string(content))
+	return err
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl"
+)
+
+func TestRbacAssignableScope(t *testing.T) {
+	tests := []struct {
+		resource string
+		expected string
+	}{
+		{resource: "/subscriptions/0000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", expected: "/subscriptions/0000"},
+		{resource: "/subscriptions/0000", expected: "/subscriptions/0000"},
+		{resource: "/providers/Microsoft.Management/managementGroups/mg", expected: "/providers/Microsoft.Management/managementGroups/mg"},
+		{resource: "https://vault.vault.azure.net", expected: ""},
+		{resource: "/providers/Microsoft.Authorization", expected: ""},
+		{resource: "", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.resource, func(t *testing.T) {
+			if result := rbacAssignableScope(test.resource); result != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestBuildRbacPlan(t *testing.T) {
+	vnet := "/subscriptions/0000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"
+	vault := "https://vault.vault.azure.net"
+
+	controlRead := func(action string) []azuretpl.FunctionPermission {
+		return []azuretpl.FunctionPermission{{Plane: azuretpl.PermissionPlaneControl, Action: action}}
+	}
+	dataRead := []azuretpl.FunctionPermission{{Plane: azuretpl.PermissionPlaneData, Action: "Microsoft.KeyVault/vaults/secrets/getSecret/action"}}
+
+	tests := []struct {
+		name                     string
+		dependencies             []azuretpl.TemplateDependency
+		assignableScopes         []string
+		expectedActions          []string
+		expectedDataActions      []string
+		expectedAssignableScopes []string
+		expectedScopes           []RbacPlanScope
+		expectedDataPlaneHosts   []RbacPlanDataPlaneHost
+		expectedUnresolved       []string
+	}{
+		{
+			name: "resource and data plane host",
+			dependencies: []azuretpl.TemplateDependency{
+				{Function: "azResource", Resource: vnet, Permissions: controlRead("Microsoft.Network/virtualNetworks/read")},
+				{Function: "azKeyVaultSecret", Resource: vault, Permissions: dataRead},
+			},
+			expectedActions:          []string{"Microsoft.Network/virtualNetworks/read"},
+			expectedDataActions:      []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"},
+			expectedAssignableScopes: []string{"/subscriptions/0000"},
+			expectedScopes:           []RbacPlanScope{{Scope: vnet, Actions: []string{"Microsoft.Network/virtualNetworks/read"}}},
+			expectedDataPlaneHosts:   []RbacPlanDataPlaneHost{{Host: vault, DataActions: []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"}}},
+			expectedUnresolved:       []string{},
+		},
+		{
+			name: "dynamic resource",
+			dependencies: []azuretpl.TemplateDependency{
+				{Function: "azResource", Permissions: controlRead(azuretpl.PermissionResourceTypeRead)},
+				{Function: "azKeyVaultSecret", Permissions: dataRead},
+			},
+			expectedActions:          []string{},
+			expectedDataActions:      []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"},
+			expectedAssignableScopes: []string{},
+			expectedScopes:           []RbacPlanScope{},
+			expectedDataPlaneHosts:   []RbacPlanDataPlaneHost{},
+			expectedUnresolved:       []string{"azResource:dynamicResource", "azKeyVaultSecret:dynamicResource"},
+		},
+		{
+			name: "unknown resource type",
+			dependencies: []azuretpl.TemplateDependency{
+				{Function: "azResource", Resource: "/subscriptions/0000", Permissions: controlRead(azuretpl.PermissionResourceTypeRead)},
+			},
+			expectedActions:          []string{},
+			expectedDataActions:      []string{},
+			expectedAssignableScopes: []string{"/subscriptions/0000"},
+			expectedScopes:           []RbacPlanScope{},
+			expectedDataPlaneHosts:   []RbacPlanDataPlaneHost{},
+			expectedUnresolved:       []string{"azResource:unknownResourceType"},
+		},
+		{
+			name: "dynamic reference",
+			dependencies: []azuretpl.TemplateDependency{
+				{
+					Function:           "azAppConfigSetting",
+					Resource:           "https://config.azconfig.io",
+					Permissions:        []azuretpl.FunctionPermission{{Plane: azuretpl.PermissionPlaneData, Action: "Microsoft.AppConfiguration/configurationStores/*/read"}},
+					DynamicPermissions: dataRead,
+				},
+			},
+			expectedActions:          []string{},
+			expectedDataActions:      []string{"Microsoft.AppConfiguration/configurationStores/*/read", "Microsoft.KeyVault/vaults/secrets/getSecret/action"},
+			expectedAssignableScopes: []string{},
+			expectedScopes:           []RbacPlanScope{},
+			expectedDataPlaneHosts:   []RbacPlanDataPlaneHost{{Host: "https://config.azconfig.io", DataActions: []string{"Microsoft.AppConfiguration/configurationStores/*/read"}}},
+			expectedUnresolved:       []string{"azAppConfigSetting:dynamicReference"},
+		},
+		{
+			name: "explicit assignable scopes",
+			dependencies: []azuretpl.TemplateDependency{
+				{Function: "azResource", Resource: vnet, Permissions: controlRead("Microsoft.Network/virtualNetworks/read")},
+			},
+			assignableScopes:         []string{"/subscriptions/1111"},
+			expectedActions:          []string{"Microsoft.Network/virtualNetworks/read"},
+			expectedDataActions:      []string{},
+			expectedAssignableScopes: []string{"/subscriptions/1111"},
+			expectedScopes:           []RbacPlanScope{{Scope: vnet, Actions: []string{"Microsoft.Network/virtualNetworks/read"}}},
+			expectedDataPlaneHosts:   []RbacPlanDataPlaneHost{},
+			expectedUnresolved:       []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts.Rbac.AssignableScopes = test.assignableScopes
+			defer func() { opts.Rbac.AssignableScopes = nil }()
+
+			plan := buildRbacPlan(test.dependencies)
+
+			if !reflect.DeepEqual(plan.RoleDefinition.Actions, test.expectedActions) {
+				t.Errorf("expected actions %v, got %v", test.expectedActions, plan.RoleDefinition.Actions)
+			}
+
+			if !reflect.DeepEqual(plan.RoleDefinition.DataActions, test.expectedDataActions) {
+				t.Errorf("expected data actions %v, got %v", test.expectedDataActions, plan.RoleDefinition.DataActions)
+			}
+
+			if !reflect.DeepEqual(plan.RoleDefinition.AssignableScopes, test.expectedAssignableScopes) {
+				t.Errorf("expected assignable scopes %v, got %v", test.expectedAssignableScopes, plan.RoleDefinition.AssignableScopes)
+			}
+
+			if !reflect.DeepEqual(plan.Scopes, test.expectedScopes) {
+				t.Errorf("expected scopes %v, got %v", test.expectedScopes, plan.Scopes)
+			}
+
+			if !reflect.DeepEqual(plan.DataPlaneHosts, test.expectedDataPlaneHosts) {
+				t.Errorf("expected data plane hosts %v, got %v", test.expectedDataPlaneHosts, plan.DataPlaneHosts)
+			}
+
+			unresolved := []string{}
+			for _, row := range plan.Unresolved {
+				unresolved = append(unresolved, row.Function+":"+row.Reason)
+			}
+			if !reflect.DeepEqual(unresolved, test.expectedUnresolved) {
+				t.Errorf("expected unresolved %v, got %v", test.expectedUnresolved, unresolved)
+			}
+		})
+	}
+}
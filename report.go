@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl"
+)
+
+const (
+	ReportFormatJson  = "json"
+	ReportFormatSarif = "sarif"
+
+	ReportLevelError = azuretpl.LintLevelError
+
+	SarifVersion = "2.1.0"
+	SarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type (
+	// Report collects findings of all processed template files
+	Report struct {
+		lock    sync.Mutex
+		Entries []ReportEntry `json:"entries"`
+	}
+
+	ReportEntry struct {
+		Level string `json:"level"`
+		Rule  string `json:"rule"`
+		azuretpl.TemplateError
+	}
+
+	sarifReport struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version,omitempty"`
+		InformationUri string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+
+	sarifRule struct {
+		Id               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+
+	sarifResult struct {
+		RuleId           string          `json:"ruleId"`
+		Level            string          `json:"level"`
+		Message          sarifMessage    `json:"message"`
+		Locations        []sarifLocation `json:"locations"`
+		RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifLocation struct {
+		Id               *int                  `json:"id,omitempty"`
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+		Message          *sarifMessage         `json:"message,omitempty"`
+	}
+
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+
+	sarifArtifactLocation struct {
+		Uri string `json:"uri"`
+	}
+
+	sarifRegion struct {
+		StartLine   int `json:"startLine,omitempty"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+var (
+	report = &Report{Entries: []ReportEntry{}}
+
+	// reportRules are the descriptions of all rules used in reports
+	reportRules = map[string]string{
+		azuretpl.LintRuleTemplateError:   "Template processing failed",
+		azuretpl.LintRuleParseError:      "Template parsing failed",
+		azuretpl.LintRuleUnknownFunction: "Template function is not defined",
+		azuretpl.LintRuleInvalidJsonPath: "Invalid jsonPath expression",
+		azuretpl.LintRuleInvalidRegexp:   "Invalid regular expression",
+		azuretpl.LintRuleRequired:        "Required value is missing",
+		azuretpl.LintRuleFail:            "Template called fail",
+		azuretpl.LintRuleKeyVaultPolicy:  "Azure KeyVault secret violates expiry policy",
+	}
+
+	// errLintFailed is returned if lint findings with level error are found (findings are already reported)
+	errLintFailed = errors.New(`lint failed`)
+)
+
+// addError adds error of template file to report
+func (r *Report) addError(templateFile TemplateFile, err error) {
+	entry := ReportEntry{
+		Level: ReportLevelError,
+		Rule:  azuretpl.LintRuleTemplateError,
+	}
+
+	var templateErr *azuretpl.TemplateError
+	if errors.As(err, &templateErr) {
+		entry.TemplateError = *templateErr
+	} else {
+		entry.File = templateFile.SourceFile
+		entry.Message = err.Error()
+	}
+
+	r.add(entry)
+}
+
+// addLintFinding adds finding of lint command to report
+func (r *Report) addLintFinding(finding azuretpl.LintFinding) {
+	r.add(ReportEntry{
+		Level:         finding.Level,
+		Rule:          finding.Rule,
+		TemplateError: finding.TemplateError,
+	})
+}
+
+func (r *Report) add(entry ReportEntry) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Entries = append(r.Entries, entry)
+}
+
+// write writes report as json or sarif file
+func (r *Report) write(path, format string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var payload interface{} = r
+	if format == ReportFormatSarif {
+		payload = r.buildSarif()
+	}
+
+	content, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return fmt.Errorf(`unable to marshal report: %w`, err)
+	}
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf(`unable to write report "%v": %w`, path, err)
+	}
+
+	return nil
+}
+
+func (r *Report) buildSarif() sarifReport {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "helm-azure-tpl",
+				Version:        gitTag,
+				InformationUri: "https://github.com/webdevops/helm-azure-tpl",
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	usedRules := map[string]bool{}
+	for _, entry := range r.Entries {
+		if !usedRules[entry.Rule] {
+			usedRules[entry.Rule] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				Id:               entry.Rule,
+				ShortDescription: sarifMessage{Text: reportRules[entry.Rule]},
+			})
+		}
+
+		message := entry.Message
+		if entry.Function != "" {
+			message = fmt.Sprintf("%v: %v", entry.Function, entry.Message)
+		}
+
+		result := sarifResult{
+			RuleId:    entry.Rule,
+			Level:     entry.Level,
+			Message:   sarifMessage{Text: message},
+			Locations: []sarifLocation{newSarifLocation(entry.TemplateLocation)},
+		}
+
+		for i, location := range entry.IncludeChain {
+			relatedLocation := newSarifLocation(location)
+			relatedLocation.Id = &i
+			relatedLocation.Message = &sarifMessage{Text: "included from here"}
+			result.RelatedLocations = append(result.RelatedLocations, relatedLocation)
+		}
+
+		run.Results = append(run.Results, result)
+	}
+
+	return sarifReport{
+		Version: SarifVersion,
+		Schema:  SarifSchema,
+		Runs:    []sarifRun{run},
+	}
+}
+
+func newSarifLocation(location azuretpl.TemplateLocation) sarifLocation {
+	ret := sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{Uri: location.File},
+		},
+	}
+
+	if location.Line > 0 {
+		ret.PhysicalLocation.Region = &sarifRegion{
+			StartLine:   location.Line,
+			StartColumn: location.Column,
+		}
+	}
+
+	return ret
+}
+
+// writeReport writes report file if enabled by --report
+func writeReport() {
+	if opts.Report.Path == "" {
+		return
+	}
+
+	logger.Info("writing report", slog.String("path", opts.Report.Path), slog.String("format", opts.Report.Format))
+	if err := report.write(opts.Report.Path, opts.Report.Format); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+}
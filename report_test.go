@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/webdevops/helm-azure-tpl/azuretpl"
+)
+
+func TestReportSarif(t *testing.T) {
+	r := &Report{Entries: []ReportEntry{}}
+	r.addError(TemplateFile{SourceFile: "plain.tpl"}, errors.New("unable to read file"))
+	r.addLintFinding(azuretpl.LintFinding{
+		Level: azuretpl.LintLevelError,
+		Rule:  azuretpl.LintRuleTemplateError,
+		TemplateError: azuretpl.TemplateError{
+			TemplateLocation: azuretpl.TemplateLocation{File: "included.tpl", Line: 3, Column: 14},
+			Function:         "azKeyVaultSecret",
+			Message:          "secret not found",
+			IncludeChain: []azuretpl.TemplateLocation{
+				{File: "main.tpl", Line: 1, Column: 3},
+				{File: "middle.tpl", Line: 2},
+			},
+		},
+	})
+	r.addLintFinding(azuretpl.LintFinding{
+		Level: azuretpl.LintLevelWarning,
+		Rule:  azuretpl.LintRuleKeyVaultPolicy,
+		TemplateError: azuretpl.TemplateError{
+			TemplateLocation: azuretpl.TemplateLocation{File: "main.tpl", Line: 5},
+			Message:          "secret expires soon",
+		},
+	})
+
+	path := filepath.Join(t.TempDir(), "report.sarif")
+	if err := r.write(path, ReportFormatSarif); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := sarifReport{}
+	if err := json.Unmarshal(content, &result); err != nil {
+		t.Fatalf("unable to parse sarif report: %v", err)
+	}
+
+	if result.Version != SarifVersion || result.Schema != SarifSchema || len(result.Runs) != 1 {
+		t.Fatalf("unexpected sarif report: %s", content)
+	}
+	run := result.Runs[0]
+
+	// rules are only listed once
+	rules := []string{}
+	for _, rule := range run.Tool.Driver.Rules {
+		rules = append(rules, rule.Id)
+		if rule.ShortDescription.Text == "" {
+			t.Errorf("rule %v has no description", rule.Id)
+		}
+	}
+	expectedRules := []string{azuretpl.LintRuleTemplateError, azuretpl.LintRuleKeyVaultPolicy}
+	if !reflect.DeepEqual(rules, expectedRules) {
+		t.Errorf("expected rules %v, got %v", expectedRules, rules)
+	}
+
+	if len(run.Results) != 3 {
+		t.Fatalf("expected 3 results, got %v", len(run.Results))
+	}
+
+	// error without template location
+	if location := run.Results[0].Locations[0].PhysicalLocation; location.ArtifactLocation.Uri != "plain.tpl" || location.Region != nil {
+		t.Errorf("unexpected location %+v", location)
+	}
+
+	// error with function and include chain
+	included := run.Results[1]
+	if included.Level != azuretpl.LintLevelError || included.Message.Text != "azKeyVaultSecret: secret not found" {
+		t.Errorf("unexpected result %+v", included)
+	}
+	if region := included.Locations[0].PhysicalLocation.Region; region == nil || region.StartLine != 3 || region.StartColumn != 14 {
+		t.Errorf("unexpected region %+v", region)
+	}
+	if len(included.RelatedLocations) != 2 {
+		t.Fatalf("expected 2 related locations, got %v", len(included.RelatedLocations))
+	}
+	for i, location := range included.RelatedLocations {
+		if location.Id == nil || *location.Id != i {
+			t.Errorf("expected related location id %v, got %v", i, location.Id)
+		}
+	}
+	if uri := included.RelatedLocations[1].PhysicalLocation.ArtifactLocation.Uri; uri != "middle.tpl" {
+		t.Errorf("expected related location middle.tpl, got %v", uri)
+	}
+
+	if warning := run.Results[2]; warning.Level != azuretpl.LintLevelWarning || warning.RuleId != azuretpl.LintRuleKeyVaultPolicy {
+		t.Errorf("unexpected result %+v", warning)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -23,61 +24,139 @@ type (
 	}
 )
 
-func (f *TemplateFile) Lint() {
+// Lint checks the template file and adds all findings to the report,
+// fails if findings with level error are found
+func (f *TemplateFile) Lint() error {
 	var buf strings.Builder
 	f.Logger.Info(`linting file`)
-	f.parse(&buf)
+
+	azureTemplate, err := f.newExecutor()
+	if err != nil {
+		return err
+	}
+
+	if err := azureTemplate.Parse(f.SourceFile, templateData, &buf); err != nil {
+		return err
+	}
+
+	errorCount := 0
+	for _, finding := range azureTemplate.LintFindings() {
+		report.addLintFinding(finding)
+
+		if finding.Level == azuretpl.LintLevelError {
+			errorCount++
+			f.Logger.Error(finding.Error(), slog.String("rule", finding.Rule))
+		} else {
+			f.Logger.Warn(finding.Error(), slog.String("rule", finding.Rule))
+		}
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf(`%w: found %d errors`, errLintFailed, errorCount)
+	}
+
 	f.Logger.Info(`file successfully linted`)
+	return nil
 }
 
-func (f *TemplateFile) Apply() {
+// Render processes the template file and returns the generated content,
+// KeyVault policy violations are added to the report
+func (f *TemplateFile) Render() (string, error) {
 	var buf strings.Builder
 	f.Logger.Info(`process file`)
-	f.parse(&buf)
 
+	azureTemplate, err := f.newExecutor()
+	if err != nil {
+		return "", err
+	}
+
+	err = azureTemplate.Parse(f.SourceFile, templateData, &buf)
+	for _, finding := range azureTemplate.LintFindings() {
+		report.addLintFinding(finding)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Apply outputs or writes the generated content
+func (f *TemplateFile) Apply(content string) error {
 	if opts.Debug {
 		fmt.Fprintln(os.Stderr)
 		fmt.Fprintln(os.Stderr, strings.Repeat("-", TermColumns))
 		fmt.Fprintf(os.Stderr, "--- %v\n", f.TargetFile)
 		fmt.Fprintln(os.Stderr, strings.Repeat("-", TermColumns))
-		fmt.Fprintln(os.Stderr, buf.String())
+		fmt.Fprintln(os.Stderr, content)
 	}
 
 	if opts.Stdout {
 		fmt.Println("--- # src: " + f.SourceFile)
-		fmt.Println(buf.String())
+		fmt.Println(content)
 		fmt.Println()
-		return
+		return nil
 	}
 
 	if !opts.DryRun {
-		f.write(&buf)
+		return f.write(content)
 	} else {
 		f.Logger.Warn(`not writing file, DRY RUN active`)
 	}
+
+	return nil
+}
+
+// Diff prints unified diff (with masked secret values) between the existing target file and content,
+// returns true if there are differences
+func (f *TemplateFile) Diff(content string) (bool, error) {
+	currentContent := ""
+	if val, err := os.ReadFile(f.TargetFile); err == nil {
+		currentContent = string(val)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf(`unable to read target file "%v": %w`, f.TargetFile, err)
+	}
+
+	// all changed lines are masked if the file contains secrets as transformed secrets can't be detected
+	diff := unifiedDiff(
+		f.TargetFile,
+		f.TargetFile+" (rendered)",
+		azuretpl.MaskSecretValues(currentContent),
+		azuretpl.MaskSecretValues(content),
+		azuretpl.SecretMaskValue,
+		azuretpl.ContainsSecretValues(currentContent) || azuretpl.ContainsSecretValues(content),
+	)
+	if diff == "" {
+		f.Logger.Info(`no differences found`)
+		return false, nil
+	}
+
+	f.Logger.Warn(`differences found`)
+	fmt.Print(diff)
+	return true, nil
 }
 
-func (f *TemplateFile) parse(buf *strings.Builder) {
+func (f *TemplateFile) newExecutor() (*azuretpl.AzureTemplateExecutor, error) {
 	ctx := f.Context
 	contextLogger := f.Logger
 
 	azureTemplate := azuretpl.New(ctx, opts.AzureTpl, contextLogger)
 	azureTemplate.SetUserAgent(UserAgent + gitTag)
 	azureTemplate.SetLintMode(lintMode)
-	azureTemplate.SetTemplateRootPath(f.TemplateBaseDir)
-	azureTemplate.SetTemplateRelPath(filepath.Dir(f.SourceFile))
-	err := azureTemplate.Parse(f.SourceFile, templateData, buf)
-	if err != nil {
-		f.Logger.Error(err.Error())
-		os.Exit(1)
+	if err := azureTemplate.SetTemplateRootPath(f.TemplateBaseDir); err != nil {
+		return nil, err
+	}
+	if err := azureTemplate.SetTemplateRelPath(filepath.Dir(f.SourceFile)); err != nil {
+		return nil, err
 	}
+	return azureTemplate, nil
 }
 
-func (f *TemplateFile) write(buf *strings.Builder) {
+func (f *TemplateFile) write(content string) error {
 	f.Logger.Info(`writing file`, slog.String("path", f.TargetFile))
-	err := os.WriteFile(f.TargetFile, []byte(buf.String()), 0600)
+	err := os.WriteFile(f.TargetFile, []byte(content), 0600)
 	if err != nil {
-		f.Logger.Error(`unable to write target file`, slog.String("path", f.TargetFile), slog.Any("error", err.Error()))
-		os.Exit(1)
+		return fmt.Errorf(`unable to write target file "%v": %w`, f.TargetFile, err)
 	}
+	return nil
 }